@@ -1,3 +1,41 @@
 package main
 
-func main() {}
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/agent"
+	"github.com/nik-de/go-metrics-svc/internal/config"
+)
+
+func main() {
+	cfg, err := config.LoadAgent(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	a, err := agent.New(agent.Config{
+		Address:        cfg.Address,
+		PollInterval:   time.Duration(cfg.PollInterval),
+		ReportInterval: time.Duration(cfg.ReportInterval),
+		Key:            cfg.Key,
+		Transport:      cfg.Transport,
+		RetryIntervals: config.Durations(cfg.RetryIntervals),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Run(ctx)
+}
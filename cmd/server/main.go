@@ -1,3 +1,144 @@
 package main
 
-func main() {}
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/nik-de/go-metrics-svc/internal/config"
+	metricsgrpc "github.com/nik-de/go-metrics-svc/internal/grpc"
+	"github.com/nik-de/go-metrics-svc/internal/handlers"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to drain.
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	cfg, err := config.LoadServer(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves until SIGINT, SIGTERM or SIGQUIT, then stops accepting
+// requests, drains the active ones and flushes the storage.
+func run(cfg config.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	s, err := newStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Bind the gRPC listener first, so a failure leaves nothing serving
+	// while the storage is closed.
+	var lis net.Listener
+	if cfg.GRPCAddress != "" {
+		if lis, err = net.Listen("tcp", cfg.GRPCAddress); err != nil {
+			s.Close()
+			return err
+		}
+	}
+
+	errc := make(chan error, 2)
+
+	srv := &http.Server{
+		Addr:    cfg.Address,
+		Handler: handlers.New(s, cfg.Key).Router(),
+	}
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errc <- err
+		}
+	}()
+
+	var gs *grpc.Server
+	if lis != nil {
+		gs = metricsgrpc.Register(s, cfg.Key)
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				errc <- err
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Print("shutting down")
+	case err = <-errc:
+		log.Printf("server failed: %v", err)
+	}
+	// Restore default signal handling so that a second signal during
+	// the drain kills the process.
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	drained := true
+	if serr := srv.Shutdown(shutdownCtx); serr != nil {
+		log.Printf("http shutdown: %v", serr)
+		drained = false
+	}
+	if gs != nil && !stopGRPC(shutdownCtx, gs) {
+		drained = false
+	}
+
+	// Once drained, every handler has returned and the final flush sees
+	// all updates. After a timeout some may still be writing.
+	if !drained {
+		log.Print("requests still in progress, the final flush may miss their updates")
+	}
+	if cerr := s.Close(); cerr != nil {
+		return errors.Join(err, cerr)
+	}
+	return err
+}
+
+// stopGRPC drains gs, cutting remaining calls off when ctx expires. It
+// reports whether every call finished before that.
+func stopGRPC(ctx context.Context, gs *grpc.Server) bool {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		log.Print("grpc shutdown: timed out, stopping")
+		gs.Stop()
+		return false
+	}
+}
+
+// newStorage picks the backend: PostgreSQL when a DSN is configured,
+// otherwise file snapshots, otherwise plain memory.
+func newStorage(ctx context.Context, cfg config.Server) (storage.Storage, error) {
+	switch {
+	case cfg.DatabaseDSN != "":
+		return storage.NewPostgresStorage(ctx, cfg.DatabaseDSN, config.Durations(cfg.RetryIntervals))
+	case cfg.FileStoragePath != "":
+		return storage.NewFileStorage(cfg.FileStoragePath, time.Duration(cfg.StoreInterval), cfg.Restore,
+			config.Durations(cfg.RetryIntervals))
+	default:
+		return storage.NewMemStorage(), nil
+	}
+}
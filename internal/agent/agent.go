@@ -0,0 +1,124 @@
+// Package agent collects runtime metrics and reports them to the server.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+// Supported report transports.
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
+// Reporter delivers a snapshot to the server.
+type Reporter interface {
+	SendBatch(ctx context.Context, ms []models.Metrics) error
+	Close() error
+}
+
+// Config holds the agent settings.
+type Config struct {
+	// Address is the server address, host:port or a URL.
+	Address        string
+	PollInterval   time.Duration
+	ReportInterval time.Duration
+	// Key signs reports with HMAC-SHA256 when set: the request body
+	// over HTTP, the request message over gRPC.
+	Key string
+	// Transport is TransportHTTP (the default) or TransportGRPC. With
+	// gRPC, Address is the server's gRPC listen address.
+	Transport string
+	// RetryIntervals are the pauses between attempts to deliver a
+	// report that failed with a transient error.
+	RetryIntervals []time.Duration
+}
+
+// Agent polls a Collector and periodically reports its snapshot.
+type Agent struct {
+	collector      *Collector
+	reporter       Reporter
+	pollInterval   time.Duration
+	reportInterval time.Duration
+}
+
+func New(cfg Config) (*Agent, error) {
+	var r Reporter
+	switch cfg.Transport {
+	case "", TransportHTTP:
+		r = NewSender(cfg.Address, cfg.Key, cfg.RetryIntervals)
+	case TransportGRPC:
+		gs, err := NewGRPCSender(cfg.Address, cfg.Key, cfg.RetryIntervals)
+		if err != nil {
+			return nil, err
+		}
+		r = gs
+	default:
+		return nil, fmt.Errorf("unknown transport %q", cfg.Transport)
+	}
+
+	return &Agent{
+		collector:      NewCollector(),
+		reporter:       r,
+		pollInterval:   cfg.PollInterval,
+		reportInterval: cfg.ReportInterval,
+	}, nil
+}
+
+// Run polls and reports until ctx is cancelled, then waits for an
+// in-flight report and releases the reporter. Reports run in the
+// background so that a slow or retrying server does not delay polls; a
+// report tick is skipped while the previous report is still running.
+func (a *Agent) Run(ctx context.Context) {
+	defer a.reporter.Close()
+
+	poll := time.NewTicker(a.pollInterval)
+	defer poll.Stop()
+	report := time.NewTicker(a.reportInterval)
+	defer report.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	reporting := make(chan struct{}, 1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			a.collector.Poll()
+		case <-report.C:
+			select {
+			case reporting <- struct{}{}:
+			default:
+				log.Print("report: previous report still running, skipping")
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-reporting }()
+				a.report(ctx)
+			}()
+		}
+	}
+}
+
+func (a *Agent) report(ctx context.Context) {
+	ms := a.collector.Snapshot()
+	if err := a.reporter.SendBatch(ctx, ms); err != nil {
+		log.Printf("report: %v", err)
+		return
+	}
+	for _, m := range ms {
+		if m.ID == pollCountID {
+			a.collector.Ack(*m.Delta)
+		}
+	}
+}
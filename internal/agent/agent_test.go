@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+// blockingReporter holds every SendBatch until release is closed.
+type blockingReporter struct {
+	sends   atomic.Int32
+	release chan struct{}
+}
+
+func (r *blockingReporter) SendBatch(ctx context.Context, _ []models.Metrics) error {
+	r.sends.Add(1)
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+	}
+	return ctx.Err()
+}
+
+func (r *blockingReporter) Close() error { return nil }
+
+func TestRunPollsWhileReporting(t *testing.T) {
+	r := &blockingReporter{release: make(chan struct{})}
+	a := &Agent{
+		collector:      NewCollector(),
+		reporter:       r,
+		pollInterval:   5 * time.Millisecond,
+		reportInterval: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pollCount(a.collector) < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d polls while a report was blocked", pollCount(a.collector))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := r.sends.Load(); got != 1 {
+		t.Errorf("sends = %d, want 1: reports must not overlap", got)
+	}
+}
+
+func pollCount(c *Collector) int64 {
+	ms := c.Snapshot()
+	return *ms[len(ms)-1].Delta
+}
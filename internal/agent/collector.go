@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+const pollCountID = "PollCount"
+
+// Collector samples runtime.MemStats and keeps the latest values until
+// they are reported.
+type Collector struct {
+	mu        sync.Mutex
+	gauges    map[string]float64
+	pollCount int64
+}
+
+func NewCollector() *Collector {
+	return &Collector{gauges: make(map[string]float64)}
+}
+
+// Poll takes a fresh sample and bumps PollCount.
+func (c *Collector) Poll() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gauges["Alloc"] = float64(ms.Alloc)
+	c.gauges["BuckHashSys"] = float64(ms.BuckHashSys)
+	c.gauges["Frees"] = float64(ms.Frees)
+	c.gauges["GCCPUFraction"] = ms.GCCPUFraction
+	c.gauges["GCSys"] = float64(ms.GCSys)
+	c.gauges["HeapAlloc"] = float64(ms.HeapAlloc)
+	c.gauges["HeapIdle"] = float64(ms.HeapIdle)
+	c.gauges["HeapInuse"] = float64(ms.HeapInuse)
+	c.gauges["HeapObjects"] = float64(ms.HeapObjects)
+	c.gauges["HeapReleased"] = float64(ms.HeapReleased)
+	c.gauges["HeapSys"] = float64(ms.HeapSys)
+	c.gauges["LastGC"] = float64(ms.LastGC)
+	c.gauges["Lookups"] = float64(ms.Lookups)
+	c.gauges["MCacheInuse"] = float64(ms.MCacheInuse)
+	c.gauges["MCacheSys"] = float64(ms.MCacheSys)
+	c.gauges["MSpanInuse"] = float64(ms.MSpanInuse)
+	c.gauges["MSpanSys"] = float64(ms.MSpanSys)
+	c.gauges["Mallocs"] = float64(ms.Mallocs)
+	c.gauges["NextGC"] = float64(ms.NextGC)
+	c.gauges["NumForcedGC"] = float64(ms.NumForcedGC)
+	c.gauges["NumGC"] = float64(ms.NumGC)
+	c.gauges["OtherSys"] = float64(ms.OtherSys)
+	c.gauges["PauseTotalNs"] = float64(ms.PauseTotalNs)
+	c.gauges["StackInuse"] = float64(ms.StackInuse)
+	c.gauges["StackSys"] = float64(ms.StackSys)
+	c.gauges["Sys"] = float64(ms.Sys)
+	c.gauges["TotalAlloc"] = float64(ms.TotalAlloc)
+	c.gauges["RandomValue"] = rand.Float64()
+
+	c.pollCount++
+}
+
+// Snapshot returns the current gauges and the PollCount delta
+// accumulated since the last Ack.
+func (c *Collector) Snapshot() []models.Metrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]models.Metrics, 0, len(c.gauges)+1)
+	for name, v := range c.gauges {
+		res = append(res, models.NewGauge(name, v))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return append(res, models.NewCounter(pollCountID, c.pollCount))
+}
+
+// Ack marks n polls as reported so they are not sent again.
+func (c *Collector) Ack(n int64) {
+	c.mu.Lock()
+	c.pollCount -= n
+	c.mu.Unlock()
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	metricsgrpc "github.com/nik-de/go-metrics-svc/internal/grpc"
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/retry"
+	pb "github.com/nik-de/go-metrics-svc/proto"
+)
+
+// GRPCSender pushes metrics over the gRPC API.
+type GRPCSender struct {
+	conn           *grpc.ClientConn
+	client         pb.MetricsClient
+	retryIntervals []time.Duration
+}
+
+// NewGRPCSender connects lazily to the gRPC server at addr. A non-empty
+// key signs every request. Calls failing with codes.Unavailable are
+// retried after each of retryIntervals.
+func NewGRPCSender(addr, key string, retryIntervals []time.Duration) (*GRPCSender, error) {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if key != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(metricsgrpc.SignUnary(key)))
+	}
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", addr, err)
+	}
+	return &GRPCSender{conn: conn, client: pb.NewMetricsClient(conn), retryIntervals: retryIntervals}, nil
+}
+
+// SendBatch reports all metrics in one UpdateMetrics call.
+func (s *GRPCSender) SendBatch(ctx context.Context, ms []models.Metrics) error {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	req := &pb.UpdateMetricsRequest{Metrics: make([]*pb.Metric, 0, len(ms))}
+	for _, m := range ms {
+		req.Metrics = append(req.Metrics, metricsgrpc.ToProto(m))
+	}
+	err := retry.Do(ctx, s.retryIntervals, isUnavailable, func() error {
+		_, err := s.client.UpdateMetrics(ctx, req)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("send batch: %w", err)
+	}
+	return nil
+}
+
+func isUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+func (s *GRPCSender) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/retry"
+	"github.com/nik-de/go-metrics-svc/internal/sign"
+)
+
+// Sender pushes metrics to the server's JSON API.
+type Sender struct {
+	client         *http.Client
+	url            string
+	key            string
+	retryIntervals []time.Duration
+}
+
+// NewSender returns a Sender for the server at addr (host:port or URL).
+// A non-empty key signs every request. Requests failing with
+// connection errors or gateway statuses are retried after each of
+// retryIntervals.
+func NewSender(addr, key string, retryIntervals []time.Duration) *Sender {
+	return &Sender{
+		client:         &http.Client{Timeout: 5 * time.Second},
+		url:            baseURL(addr),
+		key:            key,
+		retryIntervals: retryIntervals,
+	}
+}
+
+// SendBatch reports all metrics in one request.
+func (s *Sender) SendBatch(ctx context.Context, ms []models.Metrics) error {
+	if len(ms) == 0 {
+		return nil
+	}
+	if err := s.post(ctx, "/updates/", ms); err != nil {
+		return fmt.Errorf("send batch: %w", err)
+	}
+	return nil
+}
+
+func (s *Sender) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *Sender) post(ctx context.Context, path string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(s.url + path)
+	if err != nil {
+		return err
+	}
+	var sig string
+	if s.key != "" {
+		sig = sign.Sum(s.key, sign.Request(http.MethodPost, u.RequestURI(), body))
+	}
+	return retry.Do(ctx, s.retryIntervals, isRetriable, func() error {
+		return s.do(ctx, u.String(), buf.Bytes(), sig)
+	})
+}
+
+func (s *Sender) do(ctx context.Context, url string, body []byte, sig string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	if sig != "" {
+		req.Header.Set(sign.Header, sig)
+	}
+
+	var wrote atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) { wrote.Store(true) },
+	}))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if wrote.Load() {
+			return &sentError{err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	return nil
+}
+
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "server responded " + e.status
+}
+
+// sentError is a failure after the whole request was written, such as
+// a client timeout while the server is still saving. The server may
+// have applied the batch, so it is not retried; the unacknowledged
+// PollCount goes out with the next report instead.
+type sentError struct {
+	err error
+}
+
+func (e *sentError) Error() string { return e.err.Error() }
+
+func (e *sentError) Unwrap() error { return e.err }
+
+// isRetriable accepts connection failures before the request was sent
+// and the statuses a proxy returns while the server is restarting.
+func isRetriable(err error) bool {
+	var sent *sentError
+	if errors.As(err, &sent) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusBadGateway || se.code == http.StatusServiceUnavailable ||
+			se.code == http.StatusGatewayTimeout
+	}
+	return retry.IsNetwork(err)
+}
+
+func baseURL(addr string) string {
+	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
+		return addr
+	}
+	return "http://" + addr
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/sign"
+)
+
+func TestIsRetriable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&statusError{code: http.StatusBadGateway}, true},
+		{&statusError{code: http.StatusServiceUnavailable}, true},
+		{&statusError{code: http.StatusGatewayTimeout}, true},
+		{&statusError{code: http.StatusInternalServerError}, false},
+		{&statusError{code: http.StatusBadRequest}, false},
+		{syscall.ECONNREFUSED, true},
+		{&sentError{err: syscall.ECONNRESET}, false},
+		{context.Canceled, false},
+		{errors.New("encode"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetriable(tt.err); got != tt.want {
+			t.Errorf("isRetriable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSenderSendBatch(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("body is not gzip: %v", err)
+			return
+		}
+		body, _ := io.ReadAll(zr)
+		if !sign.Verify("secret", sign.Request(r.Method, r.URL.RequestURI(), body), r.Header.Get(sign.Header)) {
+			t.Error("signature does not match the request")
+		}
+		var ms []models.Metrics
+		if err := json.Unmarshal(body, &ms); err != nil || len(ms) != 2 {
+			t.Errorf("body %s: %v", body, err)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewSender(srv.URL, "secret", []time.Duration{time.Millisecond})
+	defer s.Close()
+	err := s.SendBatch(context.Background(), []models.Metrics{models.NewGauge("g", 1), models.NewCounter("c", 2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+// A timeout after the request was written may hide an update the
+// server applied, so it must not be sent again.
+func TestSenderDoesNotRetryTimeoutAfterSend(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	s := NewSender(srv.URL, "", []time.Duration{time.Millisecond, time.Millisecond})
+	s.client.Timeout = 50 * time.Millisecond
+	defer s.Close()
+
+	err := s.SendBatch(context.Background(), []models.Metrics{models.NewCounter("PollCount", 1)})
+	var sent *sentError
+	if !errors.As(err, &sent) {
+		t.Fatalf("SendBatch() = %v, want a failure after send", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestSenderFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s := NewSender(srv.URL, "", []time.Duration{time.Millisecond, time.Millisecond})
+	defer s.Close()
+	var se *statusError
+	if err := s.SendBatch(context.Background(), []models.Metrics{models.NewGauge("g", 1)}); !errors.As(err, &se) {
+		t.Fatalf("SendBatch() = %v, want a status error", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Agent holds the agent settings.
+type Agent struct {
+	Address        string   `json:"address"`
+	PollInterval   Duration `json:"poll_interval"`
+	ReportInterval Duration `json:"report_interval"`
+	Key            string   `json:"key"`
+	Transport      string   `json:"transport"`
+	// RetryIntervals are the pauses between attempts to deliver a
+	// report that failed with a transient error.
+	RetryIntervals []Duration `json:"retry_intervals"`
+}
+
+func defaultAgent() Agent {
+	return Agent{
+		Address:        "localhost:8080",
+		PollInterval:   seconds(2),
+		ReportInterval: seconds(10),
+		Transport:      "http",
+		RetryIntervals: defaultRetryIntervals(),
+	}
+}
+
+// LoadAgent builds the agent configuration from args (without the
+// program name), the environment and the optional config file.
+func LoadAgent(name string, args []string) (Agent, error) {
+	var (
+		fl           = defaultAgent()
+		path         string
+		poll, report int
+	)
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&path, "c", "", "path to a JSON config file")
+	fs.StringVar(&fl.Address, "a", fl.Address, "server address")
+	fs.IntVar(&poll, "p", int(time.Duration(fl.PollInterval)/time.Second), "poll interval in seconds")
+	fs.IntVar(&report, "r", int(time.Duration(fl.ReportInterval)/time.Second), "report interval in seconds")
+	fs.StringVar(&fl.Key, "k", fl.Key, "key for HMAC-SHA256 request signatures")
+	fs.StringVar(&fl.Transport, "t", fl.Transport, "report transport: http or grpc")
+	fs.Func("retry", "comma-separated retry intervals, empty disables retries (default \""+formatIntervals(fl.RetryIntervals)+"\")",
+		func(v string) (err error) {
+			fl.RetryIntervals, err = parseIntervals(v)
+			return err
+		})
+	if err := fs.Parse(args); err != nil {
+		return Agent{}, err
+	}
+	fl.PollInterval = seconds(poll)
+	fl.ReportInterval = seconds(report)
+
+	cfg := defaultAgent()
+	if err := loadFile(configPath(path), &cfg); err != nil {
+		return Agent{}, err
+	}
+
+	set := setFlags(fs)
+	if set["a"] {
+		cfg.Address = fl.Address
+	}
+	if set["p"] {
+		cfg.PollInterval = fl.PollInterval
+	}
+	if set["r"] {
+		cfg.ReportInterval = fl.ReportInterval
+	}
+	if set["k"] {
+		cfg.Key = fl.Key
+	}
+	if set["t"] {
+		cfg.Transport = fl.Transport
+	}
+
+	if set["retry"] {
+		cfg.RetryIntervals = fl.RetryIntervals
+	}
+
+	var e env
+	e.string("ADDRESS", &cfg.Address)
+	e.seconds("POLL_INTERVAL", &cfg.PollInterval)
+	e.seconds("REPORT_INTERVAL", &cfg.ReportInterval)
+	e.string("KEY", &cfg.Key)
+	e.string("TRANSPORT", &cfg.Transport)
+	e.intervals("RETRY_INTERVALS", &cfg.RetryIntervals)
+	if err := e.err(); err != nil {
+		return Agent{}, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (c Agent) validate() error {
+	if c.Address == "" {
+		return errors.New("server address must not be empty")
+	}
+	if c.PollInterval <= 0 || c.ReportInterval <= 0 {
+		return fmt.Errorf("intervals must be positive, got poll=%s report=%s",
+			time.Duration(c.PollInterval), time.Duration(c.ReportInterval))
+	}
+	if c.Transport != "http" && c.Transport != "grpc" {
+		return fmt.Errorf("unknown transport %q", c.Transport)
+	}
+	return nil
+}
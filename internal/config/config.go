@@ -0,0 +1,186 @@
+// Package config loads the server and agent settings. Every setting can
+// come from a JSON file (-c or CONFIG), a command-line flag or an
+// environment variable; environment variables win over flags, and flags
+// win over the file. Unset settings keep their defaults.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/retry"
+)
+
+// Duration is a time.Duration that reads from JSON either as a Go
+// duration string ("10s") or as a number of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = Duration(time.Duration(v * float64(time.Second)))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", b)
+	}
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// configPath picks the config file from CONFIG or the -c flag.
+func configPath(flagValue string) string {
+	if v, ok := os.LookupEnv("CONFIG"); ok {
+		return v
+	}
+	return flagValue
+}
+
+// loadFile decodes the JSON file at path into dst. An empty path is not
+// an error; unknown fields are.
+func loadFile(path string, dst any) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setFlags returns the names of the flags given on the command line.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// env reads environment overrides, collecting parse errors so that
+// all of them are reported at once.
+type env struct {
+	errs []error
+}
+
+func (e *env) string(name string, dst *string) {
+	if v, ok := os.LookupEnv(name); ok {
+		*dst = v
+	}
+}
+
+func (e *env) bool(name string, dst *bool) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+	*dst = b
+}
+
+// seconds reads an integer number of seconds.
+func (e *env) seconds(name string, dst *Duration) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+	*dst = Duration(time.Duration(n) * time.Second)
+}
+
+// intervals reads a comma-separated list of durations.
+func (e *env) intervals(name string, dst *[]Duration) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	ds, err := parseIntervals(v)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %w", name, err))
+		return
+	}
+	*dst = ds
+}
+
+func (e *env) err() error {
+	return errors.Join(e.errs...)
+}
+
+func seconds(n int) Duration {
+	return Duration(time.Duration(n) * time.Second)
+}
+
+func defaultRetryIntervals() []Duration {
+	ds := make([]Duration, len(retry.DefaultIntervals))
+	for i, d := range retry.DefaultIntervals {
+		ds[i] = Duration(d)
+	}
+	return ds
+}
+
+// parseIntervals parses "1s,3s,5s". An empty string disables retries.
+func parseIntervals(s string) ([]Duration, error) {
+	ds := []Duration{}
+	if strings.TrimSpace(s) == "" {
+		return ds, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("negative retry interval %s", d)
+		}
+		ds = append(ds, Duration(d))
+	}
+	return ds, nil
+}
+
+func formatIntervals(ds []Duration) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = time.Duration(d).String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Durations converts ds for use with time-based APIs.
+func Durations(ds []Duration) []time.Duration {
+	res := make([]time.Duration, len(ds))
+	for i, d := range ds {
+		res[i] = time.Duration(d)
+	}
+	return res
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var envNames = []string{
+	"CONFIG", "ADDRESS", "GRPC_ADDRESS", "STORE_INTERVAL", "FILE_STORAGE_PATH", "RESTORE",
+	"DATABASE_DSN", "KEY", "RETRY_INTERVALS", "POLL_INTERVAL", "REPORT_INTERVAL", "TRANSPORT",
+}
+
+// setEnv clears every variable the loaders read, then sets env.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, name := range envNames {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadServer(t *testing.T) {
+	file := writeFile(t, `{"address":"file:1","store_interval":"1m","restore":false,"key":"file"}`)
+	other := writeFile(t, `{"address":"other:1"}`)
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want func(*Server)
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "file",
+			args: []string{"-c", file},
+			want: func(c *Server) {
+				c.Address, c.StoreInterval, c.Restore, c.Key = "file:1", Duration(time.Minute), false, "file"
+			},
+		},
+		{
+			name: "flags win over the file",
+			args: []string{"-c", file, "-a", "flag:1", "-i", "5", "-retry", ""},
+			want: func(c *Server) {
+				c.Address, c.StoreInterval, c.Restore, c.Key = "flag:1", seconds(5), false, "file"
+				c.RetryIntervals = []Duration{}
+			},
+		},
+		{
+			name: "env wins over flags and the file",
+			args: []string{"-c", file, "-a", "flag:1", "-i", "5", "-k", "flag"},
+			env:  map[string]string{"ADDRESS": "env:1", "STORE_INTERVAL": "7", "RESTORE": "true", "RETRY_INTERVALS": "2s"},
+			want: func(c *Server) {
+				c.Address, c.StoreInterval, c.Restore, c.Key = "env:1", seconds(7), true, "flag"
+				c.RetryIntervals = []Duration{Duration(2 * time.Second)}
+			},
+		},
+		{
+			name: "CONFIG wins over -c",
+			args: []string{"-c", other},
+			env:  map[string]string{"CONFIG": file},
+			want: func(c *Server) {
+				c.Address, c.StoreInterval, c.Restore, c.Key = "file:1", Duration(time.Minute), false, "file"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			want := defaultServer()
+			if tt.want != nil {
+				tt.want(&want)
+			}
+
+			got, err := LoadServer("server", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadServer() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadAgent(t *testing.T) {
+	file := writeFile(t, `{"address":"file:1","poll_interval":3,"report_interval":"1.5s","transport":"grpc"}`)
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want func(*Agent)
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "file",
+			args: []string{"-c", file},
+			want: func(c *Agent) {
+				c.Address, c.PollInterval, c.ReportInterval, c.Transport = "file:1", seconds(3), Duration(1500*time.Millisecond), "grpc"
+			},
+		},
+		{
+			name: "flags win over the file",
+			args: []string{"-c", file, "-p", "4", "-t", "http"},
+			want: func(c *Agent) {
+				c.Address, c.PollInterval, c.ReportInterval, c.Transport = "file:1", seconds(4), Duration(1500*time.Millisecond), "http"
+			},
+		},
+		{
+			name: "env wins over flags and the file",
+			args: []string{"-p", "4", "-k", "flag"},
+			env:  map[string]string{"CONFIG": file, "POLL_INTERVAL": "9", "KEY": "env"},
+			want: func(c *Agent) {
+				c.Address, c.PollInterval, c.ReportInterval, c.Transport = "file:1", seconds(9), Duration(1500*time.Millisecond), "grpc"
+				c.Key = "env"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			want := defaultAgent()
+			if tt.want != nil {
+				tt.want(&want)
+			}
+
+			got, err := LoadAgent("agent", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadAgent() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		agent bool
+		args  []string
+		env   map[string]string
+	}{
+		{name: "unknown flag", args: []string{"-x"}},
+		{name: "bad flag value", args: []string{"-i", "soon"}},
+		{name: "bad retry flag", args: []string{"-retry", "1s,-1s"}},
+		{name: "missing file", args: []string{"-c", "/nonexistent/config.json"}},
+		{name: "unknown file field", args: []string{"-c", writeFile(t, `{"adress":"x"}`)}},
+		{name: "bad file duration", args: []string{"-c", writeFile(t, `{"store_interval":"soon"}`)}},
+		{name: "bad env", env: map[string]string{"RESTORE": "maybe", "STORE_INTERVAL": "1s"}},
+		{name: "negative store interval", args: []string{"-i", "-1"}},
+		{name: "empty address", env: map[string]string{"ADDRESS": ""}},
+		{name: "zero poll interval", agent: true, args: []string{"-p", "0"}},
+		{name: "unknown transport", agent: true, env: map[string]string{"TRANSPORT": "udp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			var err error
+			if tt.agent {
+				_, err = LoadAgent("agent", tt.args)
+			} else {
+				_, err = LoadServer("server", tt.args)
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	setEnv(t, nil)
+	if _, err := LoadServer("server", []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("LoadServer(-h) = %v, want %v", err, flag.ErrHelp)
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Duration
+		wantErr bool
+	}{
+		{in: `"10s"`, want: Duration(10 * time.Second)},
+		{in: `"1m30s"`, want: Duration(90 * time.Second)},
+		{in: `5`, want: Duration(5 * time.Second)},
+		{in: `0.5`, want: Duration(500 * time.Millisecond)},
+		{in: `"soon"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var d Duration
+		err := json.Unmarshal([]byte(tt.in), &d)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && d != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, time.Duration(d), time.Duration(tt.want))
+		}
+	}
+
+	b, err := json.Marshal(Duration(90 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"1m30s"` {
+		t.Errorf("Marshal = %s, want %q", b, "1m30s")
+	}
+}
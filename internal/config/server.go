@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Server holds the metrics server settings.
+type Server struct {
+	Address         string   `json:"address"`
+	GRPCAddress     string   `json:"grpc_address"`
+	StoreInterval   Duration `json:"store_interval"`
+	FileStoragePath string   `json:"store_file"`
+	Restore         bool     `json:"restore"`
+	DatabaseDSN     string   `json:"database_dsn"`
+	Key             string   `json:"key"`
+	// RetryIntervals are the pauses between attempts of storage
+	// operations failing with transient errors.
+	RetryIntervals []Duration `json:"retry_intervals"`
+}
+
+func defaultServer() Server {
+	return Server{
+		Address:         ":8080",
+		StoreInterval:   seconds(300),
+		FileStoragePath: "/tmp/metrics-db.json",
+		Restore:         true,
+		RetryIntervals:  defaultRetryIntervals(),
+	}
+}
+
+// LoadServer builds the server configuration from args (without the
+// program name), the environment and the optional config file.
+func LoadServer(name string, args []string) (Server, error) {
+	var (
+		fl       = defaultServer()
+		path     string
+		interval int
+	)
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&path, "c", "", "path to a JSON config file")
+	fs.StringVar(&fl.Address, "a", fl.Address, "HTTP listen address")
+	fs.StringVar(&fl.GRPCAddress, "g", fl.GRPCAddress, "gRPC listen address, empty disables gRPC")
+	fs.IntVar(&interval, "i", int(time.Duration(fl.StoreInterval)/time.Second), "store interval in seconds, 0 saves on every update")
+	fs.StringVar(&fl.FileStoragePath, "f", fl.FileStoragePath, "file storage path, empty disables saving")
+	fs.BoolVar(&fl.Restore, "r", fl.Restore, "restore metrics from the file on start")
+	fs.StringVar(&fl.DatabaseDSN, "d", fl.DatabaseDSN, "PostgreSQL DSN, takes precedence over file storage")
+	fs.StringVar(&fl.Key, "k", fl.Key, "key for HMAC-SHA256 request and response signatures")
+	fs.Func("retry", "comma-separated retry intervals, empty disables retries (default \""+formatIntervals(fl.RetryIntervals)+"\")",
+		func(v string) (err error) {
+			fl.RetryIntervals, err = parseIntervals(v)
+			return err
+		})
+	if err := fs.Parse(args); err != nil {
+		return Server{}, err
+	}
+	fl.StoreInterval = seconds(interval)
+
+	cfg := defaultServer()
+	if err := loadFile(configPath(path), &cfg); err != nil {
+		return Server{}, err
+	}
+
+	set := setFlags(fs)
+	if set["a"] {
+		cfg.Address = fl.Address
+	}
+	if set["g"] {
+		cfg.GRPCAddress = fl.GRPCAddress
+	}
+	if set["i"] {
+		cfg.StoreInterval = fl.StoreInterval
+	}
+	if set["f"] {
+		cfg.FileStoragePath = fl.FileStoragePath
+	}
+	if set["r"] {
+		cfg.Restore = fl.Restore
+	}
+	if set["d"] {
+		cfg.DatabaseDSN = fl.DatabaseDSN
+	}
+	if set["k"] {
+		cfg.Key = fl.Key
+	}
+
+	if set["retry"] {
+		cfg.RetryIntervals = fl.RetryIntervals
+	}
+
+	var e env
+	e.string("ADDRESS", &cfg.Address)
+	e.string("GRPC_ADDRESS", &cfg.GRPCAddress)
+	e.seconds("STORE_INTERVAL", &cfg.StoreInterval)
+	e.string("FILE_STORAGE_PATH", &cfg.FileStoragePath)
+	e.bool("RESTORE", &cfg.Restore)
+	e.string("DATABASE_DSN", &cfg.DatabaseDSN)
+	e.string("KEY", &cfg.Key)
+	e.intervals("RETRY_INTERVALS", &cfg.RetryIntervals)
+	if err := e.err(); err != nil {
+		return Server{}, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (c Server) validate() error {
+	if c.Address == "" {
+		return errors.New("listen address must not be empty")
+	}
+	if c.StoreInterval < 0 {
+		return fmt.Errorf("store interval must not be negative, got %s", time.Duration(c.StoreInterval))
+	}
+	return nil
+}
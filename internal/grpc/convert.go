@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	pb "github.com/nik-de/go-metrics-svc/proto"
+)
+
+// FromProto converts a wire metric into the model. Unknown types are
+// passed through as an empty type so that validation rejects them.
+func FromProto(p *pb.Metric) models.Metrics {
+	if p == nil {
+		return models.Metrics{}
+	}
+
+	m := models.Metrics{ID: p.GetId(), Labels: models.Labels(p.GetLabels()).Copy()}
+	switch p.GetType() {
+	case pb.MetricType_METRIC_TYPE_GAUGE:
+		v := p.GetValue()
+		m.MType, m.Value = models.Gauge, &v
+	case pb.MetricType_METRIC_TYPE_COUNTER:
+		d := p.GetDelta()
+		m.MType, m.Delta = models.Counter, &d
+	}
+	return m
+}
+
+// ToProto converts a stored metric into its wire form.
+func ToProto(m models.Metrics) *pb.Metric {
+	p := &pb.Metric{Id: m.ID, Type: TypeToProto(m.MType), Labels: m.Labels}
+	if m.Delta != nil {
+		p.Delta = *m.Delta
+	}
+	if m.Value != nil {
+		p.Value = *m.Value
+	}
+	return p
+}
+
+// TypeToProto maps a model type name onto the wire enum.
+func TypeToProto(mType string) pb.MetricType {
+	switch mType {
+	case models.Gauge:
+		return pb.MetricType_METRIC_TYPE_GAUGE
+	case models.Counter:
+		return pb.MetricType_METRIC_TYPE_COUNTER
+	default:
+		return pb.MetricType_METRIC_TYPE_UNSPECIFIED
+	}
+}
+
+func typeFromProto(t pb.MetricType) string {
+	switch t {
+	case pb.MetricType_METRIC_TYPE_GAUGE:
+		return models.Gauge
+	case pb.MetricType_METRIC_TYPE_COUNTER:
+		return models.Counter
+	default:
+		return ""
+	}
+}
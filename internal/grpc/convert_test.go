@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	pb "github.com/nik-de/go-metrics-svc/proto"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	labelled := models.NewGauge("g", 1.5)
+	labelled.Labels = models.Labels{"host": "a"}
+
+	for _, m := range []models.Metrics{
+		models.NewGauge("g", 0),
+		models.NewCounter("c", -3),
+		labelled,
+	} {
+		if got := FromProto(ToProto(m)); !reflect.DeepEqual(got, m) {
+			t.Errorf("FromProto(ToProto(%+v)) = %+v", m, got)
+		}
+	}
+}
+
+func TestFromProto(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *pb.Metric
+		want error
+	}{
+		{"nil", nil, models.ErrEmptyID},
+		{"unspecified type", &pb.Metric{Id: "x", Value: 1}, models.ErrUnknownType},
+		{"gauge", &pb.Metric{Id: "g", Type: pb.MetricType_METRIC_TYPE_GAUGE, Value: 2}, nil},
+		{"counter", &pb.Metric{Id: "c", Type: pb.MetricType_METRIC_TYPE_COUNTER, Delta: 2}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := FromProto(tt.p).Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// The model shares no label map with the wire message it came from.
+func TestFromProtoCopiesLabels(t *testing.T) {
+	p := &pb.Metric{Id: "g", Type: pb.MetricType_METRIC_TYPE_GAUGE, Labels: map[string]string{"a": "1"}}
+	m := FromProto(p)
+	p.Labels["a"] = "2"
+	if m.Labels["a"] != "1" {
+		t.Errorf("label changed with the message: %q", m.Labels["a"])
+	}
+}
+
+func TestTypeToProto(t *testing.T) {
+	for mType, want := range map[string]pb.MetricType{
+		models.Gauge:   pb.MetricType_METRIC_TYPE_GAUGE,
+		models.Counter: pb.MetricType_METRIC_TYPE_COUNTER,
+		"histogram":    pb.MetricType_METRIC_TYPE_UNSPECIFIED,
+	} {
+		if got := TypeToProto(mType); got != want {
+			t.Errorf("TypeToProto(%q) = %v, want %v", mType, got, want)
+		}
+		if want != pb.MetricType_METRIC_TYPE_UNSPECIFIED && typeFromProto(want) != mType {
+			t.Errorf("typeFromProto(%v) = %q, want %q", want, typeFromProto(want), mType)
+		}
+	}
+}
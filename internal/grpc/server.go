@@ -0,0 +1,90 @@
+// Package grpc serves the metrics API over gRPC on top of the same
+// storage as the HTTP handlers.
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+	pb "github.com/nik-de/go-metrics-svc/proto"
+)
+
+// Server implements pb.MetricsServer.
+type Server struct {
+	pb.UnimplementedMetricsServer
+
+	storage storage.Storage
+}
+
+func NewServer(s storage.Storage) *Server {
+	return &Server{storage: s}
+}
+
+// Register creates a grpc.Server with the metrics service registered.
+// A non-empty key makes every call carry a valid request signature.
+func Register(s storage.Storage, key string, opts ...grpc.ServerOption) *grpc.Server {
+	if key != "" {
+		opts = append(opts, grpc.ChainUnaryInterceptor(VerifyUnary(key)))
+	}
+	gs := grpc.NewServer(opts...)
+	pb.RegisterMetricsServer(gs, NewServer(s))
+	return gs
+}
+
+func (s *Server) UpdateMetric(ctx context.Context, req *pb.UpdateMetricRequest) (*pb.UpdateMetricResponse, error) {
+	m, err := s.storage.Add(ctx, FromProto(req.GetMetric()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateMetricResponse{Metric: ToProto(m)}, nil
+}
+
+func (s *Server) UpdateMetrics(ctx context.Context, req *pb.UpdateMetricsRequest) (*pb.UpdateMetricsResponse, error) {
+	ms := make([]models.Metrics, 0, len(req.GetMetrics()))
+	for _, p := range req.GetMetrics() {
+		ms = append(ms, FromProto(p))
+	}
+	if err := s.storage.AddBatch(ctx, ms); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateMetricsResponse{}, nil
+}
+
+func (s *Server) GetMetric(ctx context.Context, req *pb.GetMetricRequest) (*pb.GetMetricResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, models.ErrEmptyID.Error())
+	}
+	mType := typeFromProto(req.GetType())
+	if mType == "" {
+		return nil, status.Error(codes.InvalidArgument, models.ErrUnknownType.Error())
+	}
+
+	m, err := s.storage.Get(ctx, mType, req.GetId(), models.Labels(req.GetLabels()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetMetricResponse{Metric: ToProto(m)}, nil
+}
+
+// toStatus maps storage and validation errors onto gRPC codes, the same
+// way the HTTP handlers map them onto status codes.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrOverflow):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, models.ErrEmptyID), errors.Is(err, models.ErrUnknownType),
+		errors.Is(err, models.ErrNoValue), errors.Is(err, models.ErrNotFinite),
+		errors.Is(err, models.ErrBadLabel):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+	pb "github.com/nik-de/go-metrics-svc/proto"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{storage.ErrNotFound, codes.NotFound},
+		{fmt.Errorf("store: %w", storage.ErrOverflow), codes.OutOfRange},
+		{models.ErrEmptyID, codes.InvalidArgument},
+		{models.ErrUnknownType, codes.InvalidArgument},
+		{models.ErrNoValue, codes.InvalidArgument},
+		{models.ErrNotFinite, codes.InvalidArgument},
+		{fmt.Errorf("batch: %w", models.ErrBadLabel), codes.InvalidArgument},
+		{errors.New("connection refused"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(toStatus(tt.err)); got != tt.want {
+			t.Errorf("toStatus(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// dial serves a memory-backed metrics service with serverKey and
+// returns a client signing with clientKey.
+func dial(t *testing.T, serverKey, clientKey string) pb.MetricsClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := Register(storage.NewMemStorage(), serverKey)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	}
+	if clientKey != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(SignUnary(clientKey)))
+	}
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewMetricsClient(conn)
+}
+
+func TestServerSignatures(t *testing.T) {
+	tests := []struct {
+		name                 string
+		serverKey, clientKey string
+		want                 codes.Code
+	}{
+		{"no key", "", "", codes.OK},
+		{"signed", "secret", "secret", codes.OK},
+		{"unsigned", "secret", "", codes.Unauthenticated},
+		{"wrong key", "secret", "other", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, tt.serverKey, tt.clientKey)
+			req := &pb.UpdateMetricsRequest{Metrics: []*pb.Metric{
+				ToProto(models.NewCounter("c", 2)),
+				{Id: "g", Type: pb.MetricType_METRIC_TYPE_GAUGE, Value: 1, Labels: map[string]string{"b": "2", "a": "1"}},
+			}}
+			_, err := c.UpdateMetrics(context.Background(), req)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("UpdateMetrics: %v, want %v", err, tt.want)
+			}
+			if tt.want != codes.OK {
+				return
+			}
+
+			res, err := c.GetMetric(context.Background(), &pb.GetMetricRequest{Id: "c", Type: pb.MetricType_METRIC_TYPE_COUNTER})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.GetMetric().GetDelta(); got != 2 {
+				t.Errorf("delta = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestServerRejectsInvalid(t *testing.T) {
+	c := dial(t, "", "")
+	_, err := c.UpdateMetric(context.Background(), &pb.UpdateMetricRequest{Metric: &pb.Metric{Id: "x"}})
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Errorf("UpdateMetric: %v, want %v", err, codes.InvalidArgument)
+	}
+	_, err = c.GetMetric(context.Background(), &pb.GetMetricRequest{Id: "x", Type: pb.MetricType_METRIC_TYPE_GAUGE})
+	if got := status.Code(err); got != codes.NotFound {
+		t.Errorf("GetMetric: %v, want %v", err, codes.NotFound)
+	}
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/nik-de/go-metrics-svc/internal/sign"
+)
+
+// SignatureKey is the metadata key carrying the request signature.
+var SignatureKey = strings.ToLower(sign.Header)
+
+// Requests are signed in their deterministic wire encoding, which both
+// sides can reproduce from the decoded message.
+var marshalOpts = proto.MarshalOptions{Deterministic: true}
+
+// VerifyUnary returns an interceptor rejecting calls whose SignatureKey
+// metadata is missing or does not match the request under key.
+func VerifyUnary(key string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		sigs := md.Get(SignatureKey)
+		if len(sigs) == 0 {
+			return nil, status.Errorf(codes.Unauthenticated, "missing %s metadata", SignatureKey)
+		}
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "cannot sign %T", req)
+		}
+		data, err := marshalOpts.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if !sign.Verify(key, data, sigs[0]) {
+			return nil, status.Error(codes.Unauthenticated, "signature mismatch")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// SignUnary returns a client interceptor attaching the signature of
+// every request under key as SignatureKey metadata.
+func SignUnary(key string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "cannot sign %T", req)
+		}
+		data, err := marshalOpts.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, SignatureKey, sign.Sum(key, data))
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
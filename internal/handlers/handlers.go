@@ -0,0 +1,81 @@
+// Package handlers implements the HTTP API of the metrics server.
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nik-de/go-metrics-svc/internal/middleware"
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+)
+
+// Handler serves the metrics API on top of a storage.
+type Handler struct {
+	storage storage.Storage
+	key     string
+}
+
+// New returns a Handler backed by s. A non-empty key enables signature
+// checks on requests and signing of responses.
+func New(s storage.Storage, key string) *Handler {
+	return &Handler{storage: s, key: key}
+}
+
+// Router returns the routes served by h.
+func (h *Handler) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Gzip)
+	r.Use(middleware.Hash(h.key))
+	r.Get("/", h.Index)
+	r.Post("/update/", h.UpdateJSON)
+	r.Post("/update/{type}/{name}/{value}", h.Update)
+	r.Post("/updates/", h.Updates)
+	r.Post("/value/", h.ValueJSON)
+	r.Get("/value/{type}/{name}", h.Value)
+	r.Get("/metrics", h.List)
+	r.Get("/ping", h.Ping)
+	return r
+}
+
+// statusFor maps a storage or validation error to an HTTP status code.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, models.ErrEmptyID):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrUnknownType), errors.Is(err, models.ErrNoValue),
+		errors.Is(err, models.ErrNotFinite), errors.Is(err, models.ErrBadLabel),
+		errors.Is(err, storage.ErrOverflow):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// isJSON reports whether the request body is declared as JSON.
+func isJSON(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mt == "application/json"
+}
+
+// writeJSON encodes v before sending the status, so a value that cannot
+// be encoded yields a 500 instead of an empty 200.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, "encode response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
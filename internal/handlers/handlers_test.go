@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+)
+
+// newTestRouter serves the routes over a memory storage holding ms.
+func newTestRouter(t *testing.T, ms ...models.Metrics) (http.Handler, storage.Storage) {
+	t.Helper()
+	s := storage.NewMemStorage()
+	if err := s.AddBatch(context.Background(), ms); err != nil {
+		t.Fatal(err)
+	}
+	return New(s, "").Router(), s
+}
+
+// serve sends a request with an optional JSON body to h.
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
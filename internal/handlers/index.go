@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+//go:embed templates/index.html
+var templates embed.FS
+
+var indexTemplate = template.Must(
+	template.New("index.html").
+		Funcs(template.FuncMap{"lower": strings.ToLower}).
+		ParseFS(templates, "templates/index.html"),
+)
+
+type metricSection struct {
+	Title   string
+	Metrics []models.Metrics
+}
+
+// Index handles GET / with an HTML page of all gauges and counters,
+// each sorted by name.
+func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
+	all, err := h.storage.All(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	gauges := metricSection{Title: "Gauges"}
+	counters := metricSection{Title: "Counters"}
+	for _, m := range all {
+		if m.MType == models.Gauge {
+			gauges.Metrics = append(gauges.Metrics, m)
+		} else {
+			counters.Metrics = append(counters.Metrics, m)
+		}
+	}
+
+	// Render into a buffer so a template error still yields a clean 500.
+	var buf bytes.Buffer
+	if err := indexTemplate.Execute(&buf, []metricSection{gauges, counters}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+func TestIndex(t *testing.T) {
+	h, _ := newTestRouter(t,
+		models.NewGauge("b_gauge", 2),
+		models.NewGauge("a_gauge", 1),
+		withLabels(models.NewGauge("<script>", 3), models.Labels{"path": "<b>&"}),
+		models.NewCounter("z_counter", 5),
+		models.NewCounter("c_counter", 4),
+	)
+
+	rec := serve(h, http.MethodGet, "/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", got)
+	}
+
+	body := rec.Body.String()
+	assertOrder(t, body, "<h2>Gauges</h2>", "&lt;script&gt;", "a_gauge", "b_gauge",
+		"<h2>Counters</h2>", "c_counter", "z_counter")
+	if strings.Contains(body, "<script>") || strings.Contains(body, "<b>") {
+		t.Error("ids and label values are not HTML-escaped")
+	}
+	if !strings.Contains(body, "&lt;b&gt;&amp;") {
+		t.Error("escaped label value missing")
+	}
+}
+
+func TestIndexEmpty(t *testing.T) {
+	h, _ := newTestRouter(t)
+
+	body := serve(h, http.MethodGet, "/", "").Body.String()
+	assertOrder(t, body, "No gauges reported yet.", "No counters reported yet.")
+}
+
+// assertOrder checks that every part occurs in s after the previous one.
+func assertOrder(t *testing.T, s string, parts ...string) {
+	t.Helper()
+	rest := s
+	for _, p := range parts {
+		i := strings.Index(rest, p)
+		if i < 0 {
+			t.Fatalf("%q missing or out of order in:\n%s", p, s)
+		}
+		rest = rest[i+len(p):]
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"bufio"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// List handles GET /metrics. By default it writes the Prometheus text
+// exposition format; clients that ask for plain text/plain without a
+// version parameter get the legacy "name: value" listing.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	all, err := h.storage.All(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bw := bufio.NewWriter(w)
+	if wantsLegacyText(r) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeLegacy(bw, all)
+	} else {
+		w.Header().Set("Content-Type", prometheusContentType)
+		writePrometheus(bw, all)
+	}
+	if err := bw.Flush(); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+// wantsLegacyText reports whether Accept names text/plain without the
+// version parameter that Prometheus scrapers always send.
+func wantsLegacyText(r *http.Request) bool {
+	legacy := false
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mt != "text/plain" {
+				continue
+			}
+			if _, ok := params["version"]; ok {
+				return false
+			}
+			legacy = true
+		}
+	}
+	return legacy
+}
+
+func writeLegacy(w *bufio.Writer, ms []models.Metrics) {
+	for _, m := range ms {
+		w.WriteString(m.ID)
+		w.WriteString(m.Labels.String())
+		w.WriteString(": ")
+		w.WriteString(m.String())
+		w.WriteByte('\n')
+	}
+}
+
+// writePrometheus renders ms in the Prometheus text format, one # TYPE
+// line per metric family. Series that would make the scrape invalid are
+// left out and counted in a log line: a name already exported with the
+// other type, and a label set already exported under the same name
+// because distinct ids sanitized to it. In both cases the series that
+// comes first in storage order wins.
+func writePrometheus(w *bufio.Writer, ms []models.Metrics) {
+	// Sanitizing can merge distinct ids into one name, so regroup by
+	// the exported name and keep the storage order within a family.
+	names := make([]string, len(ms))
+	idx := make([]int, len(ms))
+	for i, m := range ms {
+		names[i] = sanitizeName(m.ID)
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return names[idx[a]] < names[idx[b]]
+	})
+
+	var (
+		lastName, lastType string
+		seen               map[string]bool
+		skipped            int
+	)
+	for _, i := range idx {
+		m, name := ms[i], names[i]
+		if name != lastName {
+			w.WriteString("# TYPE ")
+			w.WriteString(name)
+			w.WriteByte(' ')
+			w.WriteString(m.MType)
+			w.WriteByte('\n')
+			lastName, lastType = name, m.MType
+			seen = make(map[string]bool)
+		}
+
+		series := m.Labels.String()
+		if m.MType != lastType || seen[series] {
+			skipped++
+			continue
+		}
+		seen[series] = true
+
+		w.WriteString(name)
+		writePrometheusLabels(w, m.Labels)
+		w.WriteByte(' ')
+		w.WriteString(prometheusValue(m))
+		w.WriteByte('\n')
+	}
+	if skipped > 0 {
+		log.Printf("metrics: skipped %d series with colliding names", skipped)
+	}
+}
+
+func writePrometheusLabels(w *bufio.Writer, labels models.Labels) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i, k := range labels.Names() {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(k)
+		w.WriteString(`="`)
+		w.WriteString(models.EscapeLabelValue(labels[k]))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+func prometheusValue(m models.Metrics) string {
+	if m.MType == models.Counter {
+		return strconv.FormatInt(*m.Delta, 10)
+	}
+	v := *m.Value
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sanitizeName maps a metric id onto [a-zA-Z_:][a-zA-Z0-9_:]*,
+// replacing every other character with an underscore. Label names need
+// no mapping: models.Labels only accepts valid ones.
+func sanitizeName(id string) string {
+	b := []byte(id)
+	for i, c := range b {
+		ok := c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9')
+		if !ok {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func withLabels(m models.Metrics, l models.Labels) models.Metrics {
+	m.Labels = l
+	return m
+}
+
+func TestListPrometheus(t *testing.T) {
+	s := storage.NewMemStorage()
+	err := s.AddBatch(context.Background(), []models.Metrics{
+		models.NewCounter("PollCount", 7),
+		models.NewGauge("Alloc", 1.5e9),
+		withLabels(models.NewGauge("http.latency", 0.25), models.Labels{"path": `/a"b`, "host": "x\\y\nz"}),
+		models.NewGauge("http.latency", 0.5),
+		// Also sanitizes to http_latency and sorts first, so the
+		// unlabelled http.latency series is left out.
+		models.NewGauge("http-latency", 9),
+		// foo is exported as a counter, so the gauge is left out.
+		models.NewGauge("foo", 1),
+		models.NewCounter("foo", 2),
+		models.NewGauge("1st", -3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	New(s, "").Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != prometheusContentType {
+		t.Errorf("Content-Type = %q, want %q", got, prometheusContentType)
+	}
+
+	golden := filepath.Join("testdata", "metrics.golden")
+	if *update {
+		if err := os.WriteFile(golden, rec.Body.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != string(want) {
+		t.Errorf("exposition mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestListLegacy(t *testing.T) {
+	s := storage.NewMemStorage()
+	if _, err := s.Add(context.Background(), withLabels(models.NewGauge("g", 2), models.Labels{"a": "1"})); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "text/plain")
+	rec := httptest.NewRecorder()
+	New(s, "").Router().ServeHTTP(rec, req)
+
+	if got, want := rec.Body.String(), "g{a=\"1\"}: 2\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
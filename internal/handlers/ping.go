@@ -0,0 +1,12 @@
+package handlers
+
+import "net/http"
+
+// Ping handles GET /ping, reporting whether the storage backend is reachable.
+func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
+	if err := h.storage.Ping(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
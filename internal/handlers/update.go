@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+// Update handles POST /update/{type}/{name}/{value}.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	m, err := models.Parse(chi.URLParam(r, "type"), chi.URLParam(r, "name"), chi.URLParam(r, "value"))
+	if err != nil {
+		status := statusFor(err)
+		if status == http.StatusInternalServerError {
+			// strconv errors: the value does not match the type.
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if _, err := h.storage.Add(r.Context(), m); err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// UpdateJSON handles POST /update/ with a models.Metrics body and
+// responds with the stored metric.
+func (h *Handler) UpdateJSON(w http.ResponseWriter, r *http.Request) {
+	var m models.Metrics
+	if !decodeJSON(w, r, &m) {
+		return
+	}
+
+	res, err := h.storage.Add(r.Context(), m)
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Updates handles POST /updates/ with a JSON array of metrics, applied
+// as a single batch.
+func (h *Handler) Updates(w http.ResponseWriter, r *http.Request) {
+	var ms []models.Metrics
+	if !decodeJSON(w, r, &ms) {
+		return
+	}
+
+	if err := h.storage.AddBatch(r.Context(), ms); err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// decodeJSON reads a JSON value from the request body, writing the
+// error response itself when the body cannot be used.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if !isJSON(r) {
+		http.Error(w, "expected application/json body", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, io.EOF):
+			err = errors.New("empty request body")
+		case errors.As(err, &tooLarge):
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+func TestUpdateJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string // response body for 200, error message otherwise
+	}{
+		{"gauge", `{"id":"g","type":"gauge","value":1.5}`, http.StatusOK, `{"id":"g","type":"gauge","value":1.5}`},
+		{"accumulated counter", `{"id":"c","type":"counter","delta":2}`, http.StatusOK, `{"id":"c","type":"counter","delta":5}`},
+		{"missing delta", `{"id":"c","type":"counter"}`, http.StatusBadRequest, models.ErrNoValue.Error()},
+		{"missing value", `{"id":"g","type":"gauge"}`, http.StatusBadRequest, models.ErrNoValue.Error()},
+		{"empty id", `{"type":"gauge","value":1}`, http.StatusNotFound, models.ErrEmptyID.Error()},
+		{"unknown type", `{"id":"x","type":"histogram","value":1}`, http.StatusBadRequest, models.ErrUnknownType.Error()},
+		{"malformed", `{"id":`, http.StatusBadRequest, ""},
+		{"counter overflow", `{"id":"c","type":"counter","delta":9223372036854775807}`, http.StatusBadRequest, "counter overflow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestRouter(t, models.NewCounter("c", 3))
+			rec := serve(h, http.MethodPost, "/update/", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				assertJSON(t, rec.Body.String(), tt.want)
+			} else if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateJSONEmptyBody(t *testing.T) {
+	h, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/update/", nil)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != "empty request body" {
+		t.Errorf("body = %q, want %q", got, "empty request body")
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	seed := []models.Metrics{models.NewCounter("c", 1), models.NewGauge("g", 1)}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   []models.Metrics
+	}{
+		{"empty batch", `[]`, http.StatusOK, seed},
+		{"null batch", `null`, http.StatusOK, seed},
+		{
+			name:   "applied",
+			body:   `[{"id":"c","type":"counter","delta":2},{"id":"c","type":"counter","delta":3},{"id":"g","type":"gauge","value":7}]`,
+			status: http.StatusOK,
+			want:   []models.Metrics{models.NewCounter("c", 6), models.NewGauge("g", 7)},
+		},
+		{
+			name:   "one invalid entry leaves storage unchanged",
+			body:   `[{"id":"c","type":"counter","delta":2},{"id":"g","type":"gauge"}]`,
+			status: http.StatusBadRequest,
+			want:   seed,
+		},
+		{"not an array", `{"id":"c","type":"counter","delta":2}`, http.StatusBadRequest, seed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, s := newTestRouter(t, seed...)
+			rec := serve(h, http.MethodPost, "/updates/", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+
+			got, err := s.All(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stored %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONEndpointsRequireJSON(t *testing.T) {
+	h, _ := newTestRouter(t)
+	for _, target := range []string{"/update/", "/updates/", "/value/"} {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"id":"g","type":"gauge","value":1}`))
+		req.Header.Set("Content-Type", "text/plain")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("POST %s: status = %d, want %d", target, rec.Code, http.StatusUnsupportedMediaType)
+		}
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	h, _ := newTestRouter(t,
+		models.NewGauge("g", 0.5),
+		models.NewCounter("c", 4),
+		withLabels(models.NewCounter("c", 9), models.Labels{"host": "a"}),
+	)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"gauge", `{"id":"g","type":"gauge"}`, http.StatusOK, `{"id":"g","type":"gauge","value":0.5}`},
+		{"counter", `{"id":"c","type":"counter"}`, http.StatusOK, `{"id":"c","type":"counter","delta":4}`},
+		{"labelled", `{"id":"c","type":"counter","labels":{"host":"a"}}`, http.StatusOK, `{"id":"c","type":"counter","delta":9,"labels":{"host":"a"}}`},
+		{"unknown metric", `{"id":"missing","type":"gauge"}`, http.StatusNotFound, ""},
+		{"other type", `{"id":"g","type":"counter"}`, http.StatusNotFound, ""},
+		{"empty id", `{"type":"gauge"}`, http.StatusNotFound, ""},
+		{"unknown type", `{"id":"g","type":"histogram"}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, http.MethodPost, "/value/", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				assertJSON(t, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+// assertJSON compares two JSON documents ignoring formatting.
+func assertJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("response %q is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	gb, _ := json.Marshal(g)
+	wb, _ := json.Marshal(w)
+	if string(gb) != string(wb) {
+		t.Errorf("body = %s, want %s", gb, wb)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/storage"
+)
+
+// ValueJSON handles POST /value/: the body names a metric by id and type,
+// the response carries its current value.
+func (h *Handler) ValueJSON(w http.ResponseWriter, r *http.Request) {
+	var req models.Metrics
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ID == "" {
+		http.Error(w, models.ErrEmptyID.Error(), http.StatusNotFound)
+		return
+	}
+	if !models.ValidType(req.MType) {
+		http.Error(w, models.ErrUnknownType.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.storage.Get(r.Context(), req.MType, req.ID, req.Labels)
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// Value handles GET /value/{type}/{name} with the plain-text value.
+// It responds 404 for unknown metrics and 400 for an unknown type or
+// when the metric exists under the other type.
+func (h *Handler) Value(w http.ResponseWriter, r *http.Request) {
+	mType, id := chi.URLParam(r, "type"), chi.URLParam(r, "name")
+	if !models.ValidType(mType) {
+		http.Error(w, models.ErrUnknownType.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.storage.Get(r.Context(), mType, id, nil)
+	if errors.Is(err, storage.ErrNotFound) {
+		if other, ok := h.otherType(r.Context(), mType, id); ok {
+			http.Error(w, fmt.Sprintf("metric %s is a %s", id, other), http.StatusBadRequest)
+			return
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(m.String()))
+}
+
+// otherType reports whether id is stored under the type other than mType.
+func (h *Handler) otherType(ctx context.Context, mType, id string) (string, bool) {
+	other := models.Gauge
+	if mType == models.Gauge {
+		other = models.Counter
+	}
+	_, err := h.storage.Get(ctx, other, id, nil)
+	return other, err == nil
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+func TestValue(t *testing.T) {
+	h, _ := newTestRouter(t, models.NewGauge("Alloc", 1.25), models.NewCounter("PollCount", 7))
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		body   string
+	}{
+		{"gauge", "/value/gauge/Alloc", http.StatusOK, "1.25"},
+		{"counter", "/value/counter/PollCount", http.StatusOK, "7"},
+		{"unknown name", "/value/gauge/Missing", http.StatusNotFound, ""},
+		{"unknown type", "/value/histogram/Alloc", http.StatusBadRequest, ""},
+		{"name exists under the other type", "/value/counter/Alloc", http.StatusBadRequest, ""},
+		{"counter asked as gauge", "/value/gauge/PollCount", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, http.MethodGet, tt.target, "")
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package middleware holds the http.Handler wrappers shared by the server routes.
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// MaxBodySize caps request bodies after decompression, so a small
+// compressed payload cannot expand into an arbitrarily large one.
+const MaxBodySize = 10 << 20
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// Gzip transparently decompresses request bodies sent with
+// Content-Encoding: gzip and compresses responses for clients that
+// send Accept-Encoding: gzip. Decompressed bodies longer than
+// MaxBodySize fail with *http.MaxBytesError when read.
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hasToken(r.Header, "Content-Encoding", "gzip") {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, "malformed gzip body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+			r.Body = http.MaxBytesReader(w, zr, MaxBodySize)
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		}
+
+		if !hasToken(r.Header, "Accept-Encoding", "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// gzipResponseWriter compresses the body lazily, so responses without a
+// body are passed through untouched.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw     *gzip.Writer
+	status int
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.zw == nil {
+		h := w.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+
+		w.zw = gzipWriters.Get().(*gzip.Writer)
+		w.zw.Reset(w.ResponseWriter)
+	}
+	return w.zw.Write(p)
+}
+
+func (w *gzipResponseWriter) finish() {
+	if w.zw == nil {
+		if w.status != 0 {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+		return
+	}
+	w.zw.Close()
+	gzipWriters.Put(w.zw)
+}
+
+// hasToken reports whether the comma-separated header contains token,
+// ignoring parameters such as q-values.
+func hasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			part, _, _ = strings.Cut(part, ";")
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
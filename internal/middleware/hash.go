@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/nik-de/go-metrics-svc/internal/sign"
+)
+
+// Hash verifies the HashSHA256 header of incoming requests and signs
+// response bodies with key. Every request other than GET and HEAD must
+// carry a signature of its method, URI and body (see sign.Request); a
+// missing or mismatched one is rejected with 400. With an empty key the
+// middleware is a no-op.
+//
+// Hash must be mounted after Gzip so it sees uncompressed bodies.
+func Hash(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if key == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				sig := r.Header.Get(sign.Header)
+				if sig == "" {
+					http.Error(w, "missing "+sign.Header+" header", http.StatusBadRequest)
+					return
+				}
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+				if err != nil {
+					http.Error(w, err.Error(), bodyErrorStatus(err))
+					return
+				}
+				if !sign.Verify(key, sign.Request(r.Method, r.URL.RequestURI(), body), sig) {
+					http.Error(w, "signature mismatch", http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			sw := &signingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+			sw.flush(key)
+		})
+	}
+}
+
+// bodyErrorStatus maps a request body read error to a response status.
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// signingResponseWriter buffers the response so its signature can be
+// sent in a header ahead of the body.
+type signingResponseWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *signingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *signingResponseWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *signingResponseWriter) flush(key string) {
+	if w.buf.Len() > 0 {
+		w.Header().Set(sign.Header, sign.Sum(key, w.buf.Bytes()))
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/sign"
+)
+
+const testKey = "secret"
+
+func signRequest(key, method, uri string, body []byte) string {
+	return sign.Sum(key, sign.Request(method, uri, body))
+}
+
+func TestHashVerifiesRequest(t *testing.T) {
+	body := `{"id":"x","type":"counter","delta":1}`
+	tests := []struct {
+		name   string
+		method string
+		sig    string
+		want   int
+	}{
+		{name: "valid signature", method: http.MethodPost, sig: signRequest(testKey, http.MethodPost, "/update/", []byte(body)), want: http.StatusCreated},
+		{name: "bad signature", method: http.MethodPost, sig: signRequest("other", http.MethodPost, "/update/", []byte(body)), want: http.StatusBadRequest},
+		{name: "body-only signature", method: http.MethodPost, sig: sign.Sum(testKey, []byte(body)), want: http.StatusBadRequest},
+		{name: "signature for another path", method: http.MethodPost, sig: signRequest(testKey, http.MethodPost, "/updates/", []byte(body)), want: http.StatusBadRequest},
+		{name: "missing header", method: http.MethodPost, want: http.StatusBadRequest},
+		{name: "GET needs no signature", method: http.MethodGet, want: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/update/", strings.NewReader(body))
+			if tt.sig != "" {
+				req.Header.Set(sign.Header, tt.sig)
+			}
+			rec := httptest.NewRecorder()
+
+			Hash(testKey)(echo).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+// Path-based updates have an empty body, so the signature must bind the
+// path or one header value would authorize every such update.
+func TestHashRejectsReplayedPathUpdate(t *testing.T) {
+	sig := signRequest(testKey, http.MethodPost, "/update/counter/x/5", nil)
+	tests := []struct {
+		uri  string
+		want int
+	}{
+		{"/update/counter/x/5", http.StatusCreated},
+		{"/update/counter/x/999999", http.StatusBadRequest},
+		{"/update/gauge/anything/-1", http.StatusBadRequest},
+		{"/update/counter/x/5?x=1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.uri, nil)
+		req.Header.Set(sign.Header, sig)
+		rec := httptest.NewRecorder()
+
+		Hash(testKey)(echo).ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("POST %s: status = %d, want %d", tt.uri, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestHashSignsResponse(t *testing.T) {
+	body := []byte("payload")
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(sign.Header, signRequest(testKey, http.MethodPost, "/", body))
+	rec := httptest.NewRecorder()
+
+	Hash(testKey)(echo).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get(sign.Header); !sign.Verify(testKey, rec.Body.Bytes(), got) {
+		t.Errorf("response signature %q does not match body %q", got, rec.Body.String())
+	}
+}
+
+func TestHashWithoutKeyIsNoop(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+	rec := httptest.NewRecorder()
+
+	Hash("")(echo).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get(sign.Header); got != "" {
+		t.Errorf("response signed without a key: %q", got)
+	}
+}
+
+// Hash is mounted after Gzip: request signatures cover the uncompressed
+// body and response signatures the body before compression.
+func TestHashAfterGzip(t *testing.T) {
+	body := []byte("payload")
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(gzipBytes(t, body)))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set(sign.Header, signRequest(testKey, http.MethodPost, "/", body))
+	rec := httptest.NewRecorder()
+
+	Gzip(Hash(testKey)(echo)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	got := gunzipBytes(t, rec.Body.Bytes())
+	if !bytes.Equal(got, body) {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+	if sig := rec.Header().Get(sign.Header); !sign.Verify(testKey, got, sig) {
+		t.Errorf("response signature %q does not match the uncompressed body", sig)
+	}
+}
+
+func TestHashLimitsBody(t *testing.T) {
+	body := make([]byte, MaxBodySize+1)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(sign.Header, signRequest(testKey, http.MethodPost, "/", body))
+	rec := httptest.NewRecorder()
+
+	Hash(testKey)(echo).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
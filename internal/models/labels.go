@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var ErrBadLabel = errors.New("label name must match [a-zA-Z_][a-zA-Z0-9_]*")
+
+// Labels are optional key/value pairs that, together with the id and
+// type, identify a metric series.
+type Labels map[string]string
+
+// Names returns the label names in sorted order.
+func (l Labels) Names() []string {
+	names := make([]string, 0, len(l))
+	for k := range l {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String renders the labels as {a="1",b="2"} with names sorted and
+// values escaped the way the Prometheus text format expects. It is
+// empty when there are no labels, which makes it usable as part of a
+// series key.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range l.Names() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(EscapeLabelValue(l[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// validate checks every name against the Prometheus label name syntax.
+// Names are not escaped by String, so this also keeps series keys
+// unambiguous.
+func (l Labels) validate() error {
+	for k := range l {
+		if !validLabelName(k) {
+			return ErrBadLabel
+		}
+	}
+	return nil
+}
+
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range []byte(name) {
+		ok := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9')
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns an independent copy of l, or nil if l is empty.
+func (l Labels) Copy() Labels {
+	if len(l) == 0 {
+		return nil
+	}
+	c := make(Labels, len(l))
+	for k, v := range l {
+		c[k] = v
+	}
+	return c
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// EscapeLabelValue escapes backslashes, double quotes and newlines.
+func EscapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
@@ -0,0 +1,117 @@
+// Package models holds the metric schema shared by the server and its clients.
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// Supported metric types.
+const (
+	Gauge   = "gauge"
+	Counter = "counter"
+)
+
+var (
+	ErrEmptyID     = errors.New("metric id is empty")
+	ErrUnknownType = errors.New("unknown metric type")
+	ErrNoValue     = errors.New("metric value is missing")
+	ErrNotFinite   = errors.New("gauge value must be finite")
+)
+
+// Metrics is a single metric as exchanged over the JSON API.
+// Delta is set for counters, Value for gauges.
+type Metrics struct {
+	ID     string   `json:"id"`
+	MType  string   `json:"type"`
+	Delta  *int64   `json:"delta,omitempty"`
+	Value  *float64 `json:"value,omitempty"`
+	Labels Labels   `json:"labels,omitempty"`
+}
+
+// NewGauge returns a gauge metric with the given value.
+func NewGauge(id string, value float64) Metrics {
+	return Metrics{ID: id, MType: Gauge, Value: &value}
+}
+
+// NewCounter returns a counter metric with the given delta.
+func NewCounter(id string, delta int64) Metrics {
+	return Metrics{ID: id, MType: Counter, Delta: &delta}
+}
+
+// Parse builds a metric from its textual type, id and value,
+// as they appear in /update/{type}/{name}/{value}.
+func Parse(mType, id, value string) (Metrics, error) {
+	if id == "" {
+		return Metrics{}, ErrEmptyID
+	}
+	switch mType {
+	case Gauge:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Metrics{}, err
+		}
+		return NewGauge(id, v), nil
+	case Counter:
+		d, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Metrics{}, err
+		}
+		return NewCounter(id, d), nil
+	default:
+		return Metrics{}, ErrUnknownType
+	}
+}
+
+// ValidType reports whether mType is a supported metric type.
+func ValidType(mType string) bool {
+	return mType == Gauge || mType == Counter
+}
+
+// Validate checks that the metric has an id, a known type and
+// the value field matching that type, and that gauge values are finite.
+func (m Metrics) Validate() error {
+	if m.ID == "" {
+		return ErrEmptyID
+	}
+	switch m.MType {
+	case Gauge:
+		if m.Value == nil {
+			return ErrNoValue
+		}
+		// NaN and ±Inf cannot be encoded as JSON, so they would break
+		// the API responses and the file snapshots.
+		if math.IsNaN(*m.Value) || math.IsInf(*m.Value, 0) {
+			return ErrNotFinite
+		}
+	case Counter:
+		if m.Delta == nil {
+			return ErrNoValue
+		}
+	default:
+		return ErrUnknownType
+	}
+	return m.Labels.validate()
+}
+
+// Key identifies the series of m within its type: the id alone, or the
+// id and the sorted label set separated by a NUL byte so that ids
+// containing braces cannot collide with labelled series.
+func (m Metrics) Key() string {
+	if len(m.Labels) == 0 {
+		return m.ID
+	}
+	return m.ID + "\x00" + m.Labels.String()
+}
+
+// String formats the metric value the way the plain-text API returns it.
+func (m Metrics) String() string {
+	switch {
+	case m.MType == Gauge && m.Value != nil:
+		return strconv.FormatFloat(*m.Value, 'f', -1, 64)
+	case m.MType == Counter && m.Delta != nil:
+		return strconv.FormatInt(*m.Delta, 10)
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Metrics
+		want error
+	}{
+		{"gauge", NewGauge("g", 1.5), nil},
+		{"counter", NewCounter("c", 3), nil},
+		{"empty id", NewGauge("", 1), ErrEmptyID},
+		{"unknown type", Metrics{ID: "x", MType: "histogram"}, ErrUnknownType},
+		{"gauge without value", Metrics{ID: "g", MType: Gauge}, ErrNoValue},
+		{"counter without delta", Metrics{ID: "c", MType: Counter}, ErrNoValue},
+		{"NaN gauge", NewGauge("g", math.NaN()), ErrNotFinite},
+		{"+Inf gauge", NewGauge("g", math.Inf(1)), ErrNotFinite},
+		{"-Inf gauge", NewGauge("g", math.Inf(-1)), ErrNotFinite},
+		{"labels", withLabels(NewGauge("g", 1), Labels{"host": "a", "_code2": "200"}), nil},
+		{"empty label name", withLabels(NewGauge("g", 1), Labels{"": "a"}), ErrBadLabel},
+		{"label name with digit first", withLabels(NewGauge("g", 1), Labels{"2xx": "a"}), ErrBadLabel},
+		{"label name with quote", withLabels(NewGauge("g", 1), Labels{`a="1",b`: "2"}), ErrBadLabel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// Label names are not escaped in keys, so a name that embeds another
+// label used to collide with the real pair.
+func TestKeyLabelCollision(t *testing.T) {
+	a := withLabels(NewGauge("g", 1), Labels{"a": "1", "b": "2"})
+	b := withLabels(NewGauge("g", 1), Labels{`a="1",b`: "2"})
+	if a.Key() == b.Key() && b.Validate() == nil {
+		t.Errorf("%v and %v share key %q", a.Labels, b.Labels, a.Key())
+	}
+}
+
+func withLabels(m Metrics, l Labels) Metrics {
+	m.Labels = l
+	return m
+}
+
+func TestParseNonFinite(t *testing.T) {
+	for _, v := range []string{"NaN", "+Inf", "-Inf", "inf"} {
+		m, err := Parse(Gauge, "g", v)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", v, err)
+		}
+		if err := m.Validate(); !errors.Is(err, ErrNotFinite) {
+			t.Errorf("Parse(%q).Validate() = %v, want %v", v, err, ErrNotFinite)
+		}
+	}
+}
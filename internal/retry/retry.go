@@ -0,0 +1,70 @@
+// Package retry re-runs operations that failed with transient errors,
+// waiting a fixed series of intervals between attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"syscall"
+	"time"
+)
+
+// DefaultIntervals are the pauses before the second, third and fourth
+// attempt.
+var DefaultIntervals = []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}
+
+// Do calls fn until it succeeds, returns an error retriable rejects, or
+// the intervals are exhausted; len(intervals)+1 attempts are made at
+// most. The wait between attempts is cut short when ctx is done. Do
+// gives at-least-once semantics: an operation that failed after taking
+// effect may be applied twice.
+func Do(ctx context.Context, intervals []time.Duration, retriable func(error) bool, fn func() error) error {
+	err := fn()
+	for _, d := range intervals {
+		if err == nil || !retriable(err) {
+			return err
+		}
+		log.Printf("retrying in %s: %v", d, err)
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-t.C:
+		}
+		err = fn()
+	}
+	return err
+}
+
+// IsNetwork reports whether err is a connection-level failure, such as
+// a refused or reset connection or a timeout, that may clear up on its
+// own.
+func IsNetwork(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	var oe *net.OpError
+	return errors.As(err, &oe)
+}
+
+// IsTemporaryFile reports whether err is a file error that may clear up
+// on its own, e.g. a file that is temporarily locked or busy.
+func IsTemporaryFile(err error) bool {
+	return errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EWOULDBLOCK) ||
+		errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.ETXTBSY)
+}
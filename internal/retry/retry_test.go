@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func isTransient(err error) bool { return errors.Is(err, errTransient) }
+
+func TestDo(t *testing.T) {
+	intervals := []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive attempts, nil afterwards
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "recovers", errs: []error{errTransient, errTransient}, wantCalls: 3},
+		{name: "fails fast on non-retriable", errs: []error{errFatal}, wantErr: errFatal, wantCalls: 1},
+		{name: "stops at a later non-retriable", errs: []error{errTransient, errFatal}, wantErr: errFatal, wantCalls: 2},
+		{
+			name:      "attempts capped at len(intervals)+1",
+			errs:      []error{errTransient, errTransient, errTransient, errTransient, errTransient},
+			wantErr:   errTransient,
+			wantCalls: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Do(context.Background(), intervals, isTransient, func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Do() = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoNoIntervals(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), nil, isTransient, func() error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want %v after 1", err, calls, errTransient)
+	}
+}
+
+func TestDoCancelledWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := Do(ctx, []time.Duration{time.Hour}, isTransient, func() error {
+		calls++
+		return errTransient
+	})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do() waited %s after cancel", elapsed)
+	}
+	if !errors.Is(err, errTransient) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() = %v, want both the last error and context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestIsNetwork(t *testing.T) {
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route")}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{syscall.ECONNREFUSED, true},
+		{fmt.Errorf("post: %w", syscall.ECONNRESET), true},
+		{syscall.EPIPE, true},
+		{io.ErrUnexpectedEOF, true},
+		{opErr, true},
+		{os.ErrDeadlineExceeded, true},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{errors.Join(opErr, context.Canceled), false},
+		{io.EOF, false},
+		{errors.New("bad request"), false},
+	}
+	for _, tt := range tests {
+		if got := IsNetwork(tt.err); got != tt.want {
+			t.Errorf("IsNetwork(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsTemporaryFile(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&os.PathError{Op: "open", Path: "x", Err: syscall.EAGAIN}, true},
+		{syscall.EBUSY, true},
+		{syscall.EINTR, true},
+		{syscall.ETXTBSY, true},
+		{os.ErrNotExist, false},
+		{&os.PathError{Op: "open", Path: "x", Err: syscall.EACCES}, false},
+		{syscall.ENOSPC, false},
+	}
+	for _, tt := range tests {
+		if got := IsTemporaryFile(tt.err); got != tt.want {
+			t.Errorf("IsTemporaryFile(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package sign computes the HMAC-SHA256 signatures exchanged between
+// the agent and the server.
+package sign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Header carries the hex-encoded signature of a request, computed over
+// Request, or of a response body.
+const Header = "HashSHA256"
+
+// Request returns the data a request signature covers: the method, the
+// request URI and the body. Binding the URI keeps a signature from being
+// replayed against another path, which matters for the path-based
+// update whose body is empty.
+func Request(method, uri string, body []byte) []byte {
+	data := make([]byte, 0, len(method)+len(uri)+2+len(body))
+	data = append(data, method...)
+	data = append(data, ' ')
+	data = append(data, uri...)
+	data = append(data, '\n')
+	return append(data, body...)
+}
+
+// Sum returns the hex-encoded HMAC-SHA256 of data under key.
+func Sum(key string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether sig is a valid signature of data under key.
+func Verify(key string, data []byte, sig string) bool {
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/retry"
+)
+
+// FileStorage is an in-memory storage that snapshots its contents to a
+// JSON file, either after every update (interval 0) or periodically.
+type FileStorage struct {
+	*MemStorageImpl
+
+	path           string
+	syncSave       bool
+	retryIntervals []time.Duration
+
+	saveMu sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewFileStorage creates a file-backed storage at path. With restore set
+// the previous snapshot is loaded first; a missing file is not an error.
+// Reads and writes hitting a busy or locked file are retried after each
+// of retryIntervals.
+func NewFileStorage(path string, interval time.Duration, restore bool, retryIntervals []time.Duration) (*FileStorage, error) {
+	s := &FileStorage{
+		MemStorageImpl: NewMemStorage(),
+		path:           path,
+		syncSave:       interval == 0,
+		retryIntervals: retryIntervals,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	if restore {
+		err := retry.Do(context.Background(), retryIntervals, retry.IsTemporaryFile, s.restore)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.syncSave {
+		close(s.done)
+	} else {
+		go s.saveLoop(interval)
+	}
+	return s, nil
+}
+
+func (s *FileStorage) Add(ctx context.Context, m models.Metrics) (models.Metrics, error) {
+	res, err := s.MemStorageImpl.Add(ctx, m)
+	if err != nil {
+		return res, err
+	}
+	s.syncSnapshot(ctx)
+	return res, nil
+}
+
+func (s *FileStorage) AddBatch(ctx context.Context, ms []models.Metrics) error {
+	if err := s.MemStorageImpl.AddBatch(ctx, ms); err != nil {
+		return err
+	}
+	s.syncSnapshot(ctx)
+	return nil
+}
+
+// syncSnapshot saves after an update in synchronous mode. The update is
+// already applied in memory at that point, so a failed save is only
+// logged: reporting it to the client would make it resend counter
+// deltas that were counted. The next successful save or Close writes
+// the state out.
+func (s *FileStorage) syncSnapshot(ctx context.Context) {
+	if !s.syncSave {
+		return
+	}
+	if err := s.Save(ctx); err != nil {
+		log.Printf("save after update: %v", err)
+	}
+}
+
+// Save writes the current snapshot to the file.
+func (s *FileStorage) Save(ctx context.Context) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	all, err := s.MemStorageImpl.All(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return retry.Do(ctx, s.retryIntervals, retry.IsTemporaryFile, func() error {
+		return s.write(data)
+	})
+}
+
+func (s *FileStorage) write(data []byte) error {
+	// Write to a temporary file first so a crash never leaves a
+	// truncated snapshot behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save metrics: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save metrics: %w", err)
+	}
+	return nil
+}
+
+// Close stops periodic saving and writes a final snapshot.
+func (s *FileStorage) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+		close(s.stop)
+	}
+	<-s.done
+	return s.Save(context.Background())
+}
+
+func (s *FileStorage) restore() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("restore metrics: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var ms []models.Metrics
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return fmt.Errorf("restore metrics: %w", err)
+	}
+	if err := s.load(ms); err != nil {
+		return fmt.Errorf("restore metrics: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStorage) saveLoop(interval time.Duration) {
+	defer close(s.done)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			if err := s.Save(context.Background()); err != nil {
+				log.Printf("periodic save: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+func readSnapshot(t *testing.T, path string) []models.Metrics {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ms []models.Metrics
+	if err := json.Unmarshal(data, &ms); err != nil {
+		t.Fatalf("snapshot is not valid JSON: %v", err)
+	}
+	return ms
+}
+
+// A non-finite gauge used to be stored and then break every later
+// snapshot with "json: unsupported value: NaN".
+func TestFileStorageRejectsNonFiniteGauge(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	s, err := NewFileStorage(path, 0, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Add(ctx, models.NewGauge("x", math.NaN())); !errors.Is(err, models.ErrNotFinite) {
+		t.Fatalf("Add(NaN) = %v, want %v", err, models.ErrNotFinite)
+	}
+	if err := s.AddBatch(ctx, []models.Metrics{models.NewGauge("z", math.Inf(1))}); !errors.Is(err, models.ErrNotFinite) {
+		t.Fatalf("AddBatch(+Inf) = %v, want %v", err, models.ErrNotFinite)
+	}
+	if _, err := s.Add(ctx, models.NewGauge("y", 1)); err != nil {
+		t.Fatalf("Add(y) after rejected NaN: %v", err)
+	}
+
+	ms := readSnapshot(t, path)
+	if len(ms) != 1 || ms[0].ID != "y" {
+		t.Fatalf("snapshot = %+v, want only y", ms)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestFileStorageRestore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	s, err := NewFileStorage(path, time.Hour, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(ctx, models.NewCounter("c", 5)); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is written before the interval; Close does the final flush.
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("snapshot written before interval: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewFileStorage(path, 0, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := restored.Get(ctx, models.Counter, "c", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *m.Delta != 5 {
+		t.Errorf("restored c = %d, want 5", *m.Delta)
+	}
+}
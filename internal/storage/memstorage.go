@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+type gaugeSeries struct {
+	id     string
+	labels models.Labels
+	value  float64
+}
+
+type counterSeries struct {
+	id     string
+	labels models.Labels
+	delta  int64
+}
+
+// MemStorageImpl is an in-memory Storage safe for concurrent use.
+// Gauges and counters live in separate maps keyed by series key, so
+// updates and lookups do not depend on how many metrics are stored.
+type MemStorageImpl struct {
+	mu       sync.RWMutex
+	gauges   map[string]gaugeSeries
+	counters map[string]counterSeries
+}
+
+func NewMemStorage() *MemStorageImpl {
+	return &MemStorageImpl{
+		gauges:   make(map[string]gaugeSeries),
+		counters: make(map[string]counterSeries),
+	}
+}
+
+func (s *MemStorageImpl) Add(_ context.Context, m models.Metrics) (models.Metrics, error) {
+	if err := m.Validate(); err != nil {
+		return models.Metrics{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkCounters([]models.Metrics{m}); err != nil {
+		return models.Metrics{}, err
+	}
+	return s.addLocked(m), nil
+}
+
+// AddBatch validates every metric first and then applies all of them
+// under a single write lock, so readers never see a partial batch.
+func (s *MemStorageImpl) AddBatch(_ context.Context, ms []models.Metrics) error {
+	for _, m := range ms {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkCounters(ms); err != nil {
+		return err
+	}
+	for _, m := range ms {
+		s.addLocked(m)
+	}
+	return nil
+}
+
+// checkCounters returns ErrOverflow if applying ms in order would take
+// a counter outside the int64 range, so that nothing is applied.
+func (s *MemStorageImpl) checkCounters(ms []models.Metrics) error {
+	totals := make(map[string]int64)
+	for _, m := range ms {
+		if m.MType != models.Counter {
+			continue
+		}
+		key := m.Key()
+		total, ok := totals[key]
+		if !ok {
+			total = s.counters[key].delta
+		}
+		if total, ok = addInt64(total, *m.Delta); !ok {
+			return fmt.Errorf("%w: %s", ErrOverflow, m.ID)
+		}
+		totals[key] = total
+	}
+	return nil
+}
+
+// addInt64 returns a+b and whether the sum did not overflow.
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (b >= 0) == (sum >= a)
+}
+
+func (s *MemStorageImpl) addLocked(m models.Metrics) models.Metrics {
+	key := m.Key()
+	if m.MType == models.Counter {
+		c, ok := s.counters[key]
+		if !ok {
+			c = counterSeries{id: m.ID, labels: m.Labels.Copy()}
+		}
+		c.delta += *m.Delta
+		s.counters[key] = c
+		return c.metric()
+	}
+
+	g, ok := s.gauges[key]
+	if !ok {
+		g = gaugeSeries{id: m.ID, labels: m.Labels.Copy()}
+	}
+	g.value = *m.Value
+	s.gauges[key] = g
+	return g.metric()
+}
+
+func (s *MemStorageImpl) Get(_ context.Context, mType, id string, labels models.Labels) (models.Metrics, error) {
+	key := models.Metrics{ID: id, Labels: labels}.Key()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch mType {
+	case models.Gauge:
+		if g, ok := s.gauges[key]; ok {
+			return g.metric(), nil
+		}
+	case models.Counter:
+		if c, ok := s.counters[key]; ok {
+			return c.metric(), nil
+		}
+	}
+	return models.Metrics{}, ErrNotFound
+}
+
+func (s *MemStorageImpl) All(_ context.Context) ([]models.Metrics, error) {
+	s.mu.RLock()
+	res := make([]models.Metrics, 0, len(s.gauges)+len(s.counters))
+	for _, g := range s.gauges {
+		res = append(res, g.metric())
+	}
+	for _, c := range s.counters {
+		res = append(res, c.metric())
+	}
+	s.mu.RUnlock()
+
+	sortMetrics(res)
+	return res, nil
+}
+
+func (s *MemStorageImpl) Ping(context.Context) error { return nil }
+
+func (s *MemStorageImpl) Close() error { return nil }
+
+// load replaces the stored metrics with ms.
+func (s *MemStorageImpl) load(ms []models.Metrics) error {
+	for _, m := range ms {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := NewMemStorage().checkCounters(ms); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gauges = make(map[string]gaugeSeries, len(ms))
+	s.counters = make(map[string]counterSeries, len(ms))
+	for _, m := range ms {
+		s.addLocked(m)
+	}
+	return nil
+}
+
+// The stored label maps are never mutated after insertion, so metric()
+// can share them with callers that only read.
+func (g gaugeSeries) metric() models.Metrics {
+	m := models.NewGauge(g.id, g.value)
+	m.Labels = g.labels
+	return m
+}
+
+func (c counterSeries) metric() models.Metrics {
+	m := models.NewCounter(c.id, c.delta)
+	m.Labels = c.labels
+	return m
+}
+
+// sortMetrics orders metrics by id, then type, then label set.
+func sortMetrics(ms []models.Metrics) {
+	sort.Slice(ms, func(i, j int) bool {
+		a, b := ms[i], ms[j]
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.MType != b.MType {
+			return a.MType < b.MType
+		}
+		return a.Labels.String() < b.Labels.String()
+	})
+}
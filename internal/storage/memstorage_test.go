@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+func TestMemStorageAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []models.Metrics
+		want    []models.Metrics
+	}{
+		{
+			name:    "counters accumulate",
+			updates: []models.Metrics{models.NewCounter("c", 2), models.NewCounter("c", 3)},
+			want:    []models.Metrics{models.NewCounter("c", 5)},
+		},
+		{
+			name:    "gauges overwrite",
+			updates: []models.Metrics{models.NewGauge("g", 2), models.NewGauge("g", 0.5)},
+			want:    []models.Metrics{models.NewGauge("g", 0.5)},
+		},
+		{
+			name:    "same name under another type stays separate",
+			updates: []models.Metrics{models.NewGauge("m", 1.5), models.NewCounter("m", 4)},
+			want:    []models.Metrics{models.NewCounter("m", 4), models.NewGauge("m", 1.5)},
+		},
+		{
+			name: "All sorts by id, type and labels",
+			updates: []models.Metrics{
+				models.NewGauge("b", 1),
+				withLabels(models.NewGauge("a", 3), models.Labels{"host": "y"}),
+				models.NewCounter("a", 1),
+				withLabels(models.NewGauge("a", 2), models.Labels{"host": "x"}),
+			},
+			want: []models.Metrics{
+				models.NewCounter("a", 1),
+				withLabels(models.NewGauge("a", 2), models.Labels{"host": "x"}),
+				withLabels(models.NewGauge("a", 3), models.Labels{"host": "y"}),
+				models.NewGauge("b", 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewMemStorage()
+			for _, m := range tt.updates {
+				if _, err := s.Add(ctx, m); err != nil {
+					t.Fatalf("Add(%+v): %v", m, err)
+				}
+			}
+			got, err := s.All(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("All() = %s, want %s", dump(got), dump(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemStorageAddReturnsAccumulated(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemStorage()
+	if _, err := s.Add(ctx, models.NewCounter("c", 2)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Add(ctx, models.NewCounter("c", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got.Delta != 5 {
+		t.Errorf("Add returned delta %d, want 5", *got.Delta)
+	}
+}
+
+func TestMemStorageAddBatchIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemStorage()
+	err := s.AddBatch(ctx, []models.Metrics{
+		models.NewCounter("ok", 1),
+		{ID: "broken", MType: models.Gauge},
+	})
+	if !errors.Is(err, models.ErrNoValue) {
+		t.Fatalf("AddBatch() = %v, want %v", err, models.ErrNoValue)
+	}
+	all, _ := s.All(ctx)
+	if len(all) != 0 {
+		t.Errorf("invalid batch was partially applied: %s", dump(all))
+	}
+}
+
+func TestMemStorageCounterOverflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		start int64
+		batch []int64
+	}{
+		{"above max", math.MaxInt64 - 1, []int64{2}},
+		{"below min", math.MinInt64 + 1, []int64{-2}},
+		{"within one batch", 0, []int64{math.MaxInt64, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewMemStorage()
+			if _, err := s.Add(ctx, models.NewCounter("c", tt.start)); err != nil {
+				t.Fatal(err)
+			}
+
+			var batch []models.Metrics
+			for _, d := range tt.batch {
+				batch = append(batch, models.NewCounter("c", d))
+			}
+			if err := s.AddBatch(ctx, batch); !errors.Is(err, ErrOverflow) {
+				t.Errorf("AddBatch() = %v, want %v", err, ErrOverflow)
+			}
+			if len(tt.batch) == 1 {
+				if _, err := s.Add(ctx, batch[0]); !errors.Is(err, ErrOverflow) {
+					t.Errorf("Add() = %v, want %v", err, ErrOverflow)
+				}
+			}
+
+			got, err := s.Get(ctx, models.Counter, "c", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got.Delta != tt.start {
+				t.Errorf("delta = %d after a rejected update, want %d", *got.Delta, tt.start)
+			}
+		})
+	}
+}
+
+func TestMemStorageGetLabels(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemStorage()
+	labels := models.Labels{"host": "a", "code": "200"}
+	if err := s.AddBatch(ctx, []models.Metrics{
+		models.NewCounter("req", 1),
+		withLabels(models.NewCounter("req", 7), labels),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		labels models.Labels
+		want   int64
+		err    error
+	}{
+		{"unlabelled", nil, 1, nil},
+		{"empty labels match unlabelled", models.Labels{}, 1, nil},
+		{"labelled", models.Labels{"code": "200", "host": "a"}, 7, nil},
+		{"label subset is another series", models.Labels{"host": "a"}, 0, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := s.Get(ctx, models.Counter, "req", tt.labels)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && *m.Delta != tt.want {
+				t.Errorf("Get() delta = %d, want %d", *m.Delta, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.Get(ctx, models.Gauge, "req", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(gauge) = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func withLabels(m models.Metrics, labels models.Labels) models.Metrics {
+	m.Labels = labels
+	return m
+}
+
+func dump(ms []models.Metrics) string {
+	res := "["
+	for i, m := range ms {
+		if i > 0 {
+			res += " "
+		}
+		res += m.MType + ":" + m.ID + m.Labels.String() + "=" + m.String()
+	}
+	return res + "]"
+}
+
+func benchMetrics(n int) []models.Metrics {
+	ms := make([]models.Metrics, n)
+	for i := range ms {
+		id := "metric_" + strconv.Itoa(i)
+		if i%2 == 0 {
+			ms[i] = models.NewGauge(id, float64(i))
+		} else {
+			ms[i] = models.NewCounter(id, 1)
+		}
+	}
+	return ms
+}
+
+func BenchmarkMemStorageAdd(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{10, 1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("names=%d", n), func(b *testing.B) {
+			s := NewMemStorage()
+			ms := benchMetrics(n)
+			if err := s.AddBatch(ctx, ms); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Add(ctx, ms[i%n]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMemStorageAddParallel(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("names=%d", n), func(b *testing.B) {
+			s := NewMemStorage()
+			ms := benchMetrics(n)
+			if err := s.AddBatch(ctx, ms); err != nil {
+				b.Fatal(err)
+			}
+
+			var next atomic.Int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := int(next.Add(1)) % n
+					if _, err := s.Add(ctx, ms[i]); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkMemStorageGet(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{10, 1_000, 100_000} {
+		b.Run(fmt.Sprintf("names=%d", n), func(b *testing.B) {
+			s := NewMemStorage()
+			ms := benchMetrics(n)
+			if err := s.AddBatch(ctx, ms); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m := ms[i%n]
+				if _, err := s.Get(ctx, m.MType, m.ID, m.Labels); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+	"github.com/nik-de/go-metrics-svc/internal/retry"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// PostgresStorage keeps metrics in a PostgreSQL table.
+type PostgresStorage struct {
+	pool           *pgxpool.Pool
+	retryIntervals []time.Duration
+}
+
+// NewPostgresStorage connects to dsn and applies pending migrations.
+// Operations failing with connection errors are retried after each of
+// retryIntervals, which also covers a database that is still starting.
+// Writes are only retried when they cannot have reached the database,
+// since the counter upsert is not idempotent.
+func NewPostgresStorage(ctx context.Context, dsn string, retryIntervals []time.Duration) (*PostgresStorage, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+
+	s := &PostgresStorage{pool: pool, retryIntervals: retryIntervals}
+	if err := s.retry(ctx, func() error { return s.migrate(ctx) }); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+const upsertQuery = `
+INSERT INTO metrics (id, mtype, delta, value, labels) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id, mtype, labels) DO UPDATE SET
+    delta = metrics.delta + EXCLUDED.delta,
+    value = EXCLUDED.value
+RETURNING delta, value`
+
+func (s *PostgresStorage) Add(ctx context.Context, m models.Metrics) (models.Metrics, error) {
+	if err := m.Validate(); err != nil {
+		return models.Metrics{}, err
+	}
+
+	res := models.Metrics{ID: m.ID, MType: m.MType, Labels: m.Labels.Copy()}
+	err := s.retryWrite(ctx, func() error {
+		return s.pool.QueryRow(ctx, upsertQuery, m.ID, m.MType, m.Delta, m.Value, labelsArg(m.Labels)).
+			Scan(&res.Delta, &res.Value)
+	})
+	if err != nil {
+		return models.Metrics{}, fmt.Errorf("store metric %s: %w", m.ID, overflowErr(err))
+	}
+	return res, nil
+}
+
+// AddBatch applies ms in a single transaction. A transaction that fails
+// before reaching the database is retried as a whole.
+func (s *PostgresStorage) AddBatch(ctx context.Context, ms []models.Metrics) error {
+	for _, m := range ms {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.retryWrite(ctx, func() error { return s.addBatch(ctx, ms) }); err != nil {
+		return fmt.Errorf("store metrics: %w", overflowErr(err))
+	}
+	return nil
+}
+
+func (s *PostgresStorage) addBatch(ctx context.Context, ms []models.Metrics) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, m := range ms {
+		batch.Queue(upsertQuery, m.ID, m.MType, m.Delta, m.Value, labelsArg(m.Labels))
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *PostgresStorage) Get(ctx context.Context, mType, id string, labels models.Labels) (models.Metrics, error) {
+	m := models.Metrics{ID: id, MType: mType, Labels: labels.Copy()}
+	err := s.retry(ctx, func() error {
+		return s.pool.QueryRow(ctx,
+			`SELECT delta, value FROM metrics WHERE id = $1 AND mtype = $2 AND labels = $3`,
+			id, mType, labelsArg(labels),
+		).Scan(&m.Delta, &m.Value)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Metrics{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Metrics{}, fmt.Errorf("load metric %s: %w", id, err)
+	}
+	return m, nil
+}
+
+func (s *PostgresStorage) All(ctx context.Context) ([]models.Metrics, error) {
+	var res []models.Metrics
+	err := s.retry(ctx, func() (err error) {
+		res, err = s.all(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load metrics: %w", err)
+	}
+	// Sorted here rather than in SQL so the order matches the other
+	// backends, which compare labels by their rendered form.
+	sortMetrics(res)
+	return res, nil
+}
+
+func (s *PostgresStorage) all(ctx context.Context) ([]models.Metrics, error) {
+	rows, err := s.pool.Query(ctx, `SELECT id, mtype, delta, value, labels FROM metrics`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []models.Metrics
+	for rows.Next() {
+		var m models.Metrics
+		if err := rows.Scan(&m.ID, &m.MType, &m.Delta, &m.Value, &m.Labels); err != nil {
+			return nil, err
+		}
+		m.Labels = m.Labels.Copy()
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+func (s *PostgresStorage) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+func (s *PostgresStorage) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// retry re-runs idempotent operations: reads and migrations.
+func (s *PostgresStorage) retry(ctx context.Context, fn func() error) error {
+	return retry.Do(ctx, s.retryIntervals, isRetriablePG, fn)
+}
+
+// retryWrite re-runs the upserts, which must not be applied twice.
+func (s *PostgresStorage) retryWrite(ctx context.Context, fn func() error) error {
+	return retry.Do(ctx, s.retryIntervals, isRetriablePGWrite, fn)
+}
+
+// isRetriablePG accepts connection exceptions (SQLSTATE class 08) and
+// network failures; constraint violations and the like fail fast.
+func isRetriablePG(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgerrcode.IsConnectionException(pgErr.Code)
+	}
+	return safeToRetry(err) || retry.IsNetwork(err)
+}
+
+// isRetriablePGWrite is isRetriablePG restricted to failures that
+// happened before the statement was sent: a connection that broke
+// afterwards may already have applied it, or committed the transaction.
+func isRetriablePGWrite(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgerrcode.IsConnectionException(pgErr.Code)
+	}
+	if safeToRetry(err) {
+		return true
+	}
+	// pgconn does not export its connect error, but it wraps the dial
+	// failure, and nothing is sent before the dial succeeds.
+	var oe *net.OpError
+	return errors.As(err, &oe) && oe.Op == "dial"
+}
+
+// safeToRetry is pgconn.SafeToRetry looking through wrapped errors.
+func safeToRetry(err error) bool {
+	var e interface{ SafeToRetry() bool }
+	return errors.As(err, &e) && e.SafeToRetry()
+}
+
+// overflowErr reports a counter sum outside the bigint range as
+// ErrOverflow, matching the other backends.
+func overflowErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.NumericValueOutOfRange {
+		return fmt.Errorf("%w: %w", ErrOverflow, err)
+	}
+	return err
+}
+
+// labelsArg returns the JSONB parameter for labels. Unlabelled series
+// are stored as an empty object so they stay unique under the primary key.
+func labelsArg(labels models.Labels) models.Labels {
+	if labels == nil {
+		return models.Labels{}
+	}
+	return labels
+}
+
+// migrate applies every embedded migration newer than the recorded
+// schema version, each in its own transaction.
+func (s *PostgresStorage) migrate(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	var current int
+	err = s.pool.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, name := range files {
+		version, err := migrationVersion(name)
+		if err != nil {
+			return err
+		}
+		if version <= current {
+			continue
+		}
+		if err := s.applyMigration(ctx, name, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStorage) applyMigration(ctx context.Context, name string, version int) error {
+	sql, err := migrations.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", name, err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", name, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, string(sql)); err != nil {
+		return fmt.Errorf("migrate %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return fmt.Errorf("migrate %s: %w", name, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("migrate %s: %w", name, err)
+	}
+	return nil
+}
+
+// migrationVersion extracts the numeric prefix of "migrations/0001_name.sql".
+func migrationVersion(name string) (int, error) {
+	base := strings.TrimPrefix(name, "migrations/")
+	prefix, _, _ := strings.Cut(base, "_")
+	v, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migration %s: bad version prefix", name)
+	}
+	return v, nil
+}
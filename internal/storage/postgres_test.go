@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"reflect"
+	"sort"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+// pgconnErr mimics the unexported pgconn error that records whether
+// the failure happened before anything was sent.
+type pgconnErr struct {
+	safe bool
+	err  error
+}
+
+func (e *pgconnErr) Error() string     { return "pgconn: " + e.err.Error() }
+func (e *pgconnErr) SafeToRetry() bool { return e.safe }
+func (e *pgconnErr) Unwrap() error     { return e.err }
+
+func TestIsRetriablePG(t *testing.T) {
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	tests := []struct {
+		name        string
+		err         error
+		read, write bool
+	}{
+		{"connection exception", &pgconn.PgError{Code: pgerrcode.ConnectionFailure}, true, true},
+		{"admin shutdown is not class 08", &pgconn.PgError{Code: pgerrcode.AdminShutdown}, false, false},
+		{"unique violation", &pgconn.PgError{Code: pgerrcode.UniqueViolation}, false, false},
+		{"failed before send", &pgconnErr{safe: true, err: reset}, true, true},
+		{"reset after send", &pgconnErr{safe: false, err: reset}, true, false},
+		{"wrapped reset after send", fmt.Errorf("commit: %w", &pgconnErr{err: reset}), true, false},
+		{"dial failure", fmt.Errorf("connect: %w", dial), true, true},
+		{"bare reset", reset, true, false},
+		{"cancelled", context.Canceled, false, false},
+		{"other", errors.New("boom"), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetriablePG(tt.err); got != tt.read {
+				t.Errorf("isRetriablePG() = %v, want %v", got, tt.read)
+			}
+			if got := isRetriablePGWrite(tt.err); got != tt.write {
+				t.Errorf("isRetriablePGWrite() = %v, want %v", got, tt.write)
+			}
+		})
+	}
+}
+
+func TestOverflowErr(t *testing.T) {
+	outOfRange := &pgconn.PgError{Code: pgerrcode.NumericValueOutOfRange, Message: "bigint out of range"}
+	if err := overflowErr(outOfRange); !errors.Is(err, ErrOverflow) || !errors.Is(err, outOfRange) {
+		t.Errorf("overflowErr(out of range) = %v, want ErrOverflow wrapping the cause", err)
+	}
+	other := &pgconn.PgError{Code: pgerrcode.UniqueViolation}
+	if err := overflowErr(other); errors.Is(err, ErrOverflow) {
+		t.Errorf("overflowErr(%v) = %v", other, err)
+	}
+}
+
+func TestMigrationVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+	}{
+		{name: "migrations/0001_create_metrics.sql", want: 1},
+		{name: "migrations/0042_add_index.sql", want: 42},
+		{name: "migrations/create_metrics.sql", wantErr: true},
+		{name: "migrations/.sql", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := migrationVersion(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("migrationVersion(%q) = %d, %v, want %d (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// The embedded migrations must have distinct, increasing versions.
+func TestEmbeddedMigrations(t *testing.T) {
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	sort.Strings(files)
+	prev := 0
+	for _, name := range files {
+		v, err := migrationVersion(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v <= prev {
+			t.Errorf("%s: version %d not above %d", name, v, prev)
+		}
+		prev = v
+	}
+}
+
+func TestLabelsArg(t *testing.T) {
+	if got := labelsArg(nil); got == nil || len(got) != 0 {
+		t.Errorf("labelsArg(nil) = %#v, want an empty map", got)
+	}
+	// An empty object, not JSON null, keeps unlabelled rows unique.
+	b, err := json.Marshal(labelsArg(nil))
+	if err != nil || string(b) != "{}" {
+		t.Errorf("labelsArg(nil) encodes as %s, %v, want {}", b, err)
+	}
+	l := models.Labels{"host": "a"}
+	if got := labelsArg(l); !reflect.DeepEqual(got, l) {
+		t.Errorf("labelsArg(%v) = %v", l, got)
+	}
+}
@@ -0,0 +1,35 @@
+// Package storage keeps the current value of every reported metric.
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nik-de/go-metrics-svc/internal/models"
+)
+
+var (
+	ErrNotFound = errors.New("metric not found")
+	// ErrOverflow rejects a counter delta that would take the total
+	// outside the int64 range.
+	ErrOverflow = errors.New("counter overflow")
+)
+
+// Storage is the storage used by the HTTP handlers.
+type Storage interface {
+	// Add stores a gauge value or accumulates a counter delta and
+	// returns the resulting metric.
+	Add(ctx context.Context, m models.Metrics) (models.Metrics, error)
+	// AddBatch applies all metrics atomically: either every update is
+	// stored or none is.
+	AddBatch(ctx context.Context, ms []models.Metrics) error
+	// Get returns the series with the given type, id and labels or
+	// ErrNotFound. Nil labels select the unlabelled series.
+	Get(ctx context.Context, mType, id string, labels models.Labels) (models.Metrics, error)
+	// All returns every stored metric sorted by id, type and labels.
+	All(ctx context.Context) ([]models.Metrics, error)
+	// Ping checks that the backend is reachable.
+	Ping(ctx context.Context) error
+	// Close flushes pending state and releases the backend.
+	Close() error
+}